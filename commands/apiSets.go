@@ -45,6 +45,24 @@ func (j judgementSet) bySubmissionId(id string) (judgementSet, bool) {
 	return jud, len(jud) > 0
 }
 
+// final returns the most recent judgement that has a judgement type, i.e. the judgement that is done.
+func (j judgementSet) final() (interactor.Judgement, bool) {
+	var best interactor.Judgement
+	var found bool
+	for _, ju := range j {
+		if ju.JudgementTypeId == "" {
+			continue
+		}
+
+		if !found || ju.EndContestTime >= best.EndContestTime {
+			best = ju
+			found = true
+		}
+	}
+
+	return best, found
+}
+
 func (p problemSet) byId(id string) (interactor.Problem, bool) {
 	for _, problem := range p {
 		if strings.EqualFold(problem.Id, id) || strings.EqualFold(problem.Label, id) || strings.EqualFold(problem.Name, id) {
@@ -0,0 +1,519 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/kirsle/configdir"
+	"github.com/spf13/viper"
+)
+
+// staticTTL is how long collections that hardly change during a contest are used without asking the server
+const staticTTL = 24 * time.Hour
+
+// cacheTTLs is how long a response of an endpoint is used without asking the server again. Endpoints that are not
+// listed change during the contest, so they are revalidated every time they are used. The TTLs can be changed with
+// cache.ttl.<endpoint> in the configuration file.
+var cacheTTLs = map[string]time.Duration{
+	"contests":        time.Hour,
+	"contest":         time.Hour,
+	"problems":        staticTTL,
+	"languages":       staticTTL,
+	"judgement-types": staticTTL,
+	"teams":           staticTTL,
+	"organizations":   staticTTL,
+	"groups":          staticTTL,
+}
+
+var errOffline = errors.New("not available in offline mode")
+
+// cachedResponse is a response of the Contest API as stored on disk.
+type cachedResponse struct {
+	Url          string          `json:"url"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	Retrieved    time.Time       `json:"retrieved"`
+	Body         json.RawMessage `json:"body"`
+}
+
+// responseCache keeps the responses of the Contest API on disk, keyed by the base URL, user and path, which contains
+// the contest and endpoint. Responses are revalidated with conditional requests when their TTL has passed. In offline
+// mode the server is never asked, and the last response retrieved is used regardless of its age.
+type responseCache struct {
+	api     rawApi
+	dir     string
+	offline bool
+}
+
+// cacheDir returns the directory to keep responses in, which can be changed with cache.dir in the configuration file.
+func cacheDir() string {
+	if dir := viper.GetString("cache.dir"); dir != "" {
+		return dir
+	}
+
+	return filepath.Join(configdir.LocalCache(configFolder), "responses")
+}
+
+func newResponseCache(api rawApi) responseCache {
+	return responseCache{
+		api:     api,
+		dir:     cacheDir(),
+		offline: offline,
+	}
+}
+
+// cacheTTL returns how long responses of the endpoint are used without asking the server.
+func cacheTTL(endpoint string) time.Duration {
+	if key := "cache.ttl." + endpoint; viper.IsSet(key) {
+		return viper.GetDuration(key)
+	}
+
+	return cacheTTLs[endpoint]
+}
+
+// filename returns the file the response for the path is kept in.
+func (c responseCache) filename(path string) string {
+	hash := sha256.Sum256([]byte(c.api.baseUrl + "\x00" + c.api.username + "\x00" + path))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+".json")
+}
+
+// getJSON decodes the response for the path into v. The endpoint determines how long a cached response is used.
+// Empty collections are always revalidated, as teams do not see the problems before the contest starts.
+func (c responseCache) getJSON(path, endpoint string, v interface{}) error {
+	filename := c.filename(path)
+	cached, hasCached := readCachedResponse(filename)
+
+	if c.offline {
+		if !hasCached {
+			return fmt.Errorf("%s was never retrieved, so it is %w", path, errOffline)
+		}
+
+		return json.Unmarshal(cached.Body, v)
+	}
+
+	ttl := cacheTTL(endpoint)
+	if hasCached && cmdCtx.now().Sub(cached.Retrieved) < ttl && !bytes.Equal(bytes.TrimSpace(cached.Body), []byte("[]")) {
+		return json.Unmarshal(cached.Body, v)
+	}
+
+	resp, modified, err := c.api.getConditional(context.Background(), path, cached.ETag, cached.LastModified)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && hasCached {
+		return fmt.Errorf("%w; use --offline to use the response retrieved at %s", err, cached.Retrieved.Local().Format(time.RFC1123))
+	} else if err != nil {
+		return err
+	}
+
+	unchanged := !modified
+	if modified {
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("could not read response; %w", err)
+		}
+
+		unchanged = hasCached && bytes.Equal(body, cached.Body)
+		cached = cachedResponse{
+			Url:          resp.Request.URL.String(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Body:         body,
+		}
+	}
+
+	// Endpoints without a TTL do not need the time they were retrieved, so they are only written when they changed.
+	// That keeps polling, e.g. for the judgement of a submission, from rewriting the same response over and over.
+	if !unchanged || ttl > 0 {
+		cached.Retrieved = cmdCtx.now()
+		if err := writeCachedResponse(filename, cached); err != nil {
+			fmt.Fprintf(cmdCtx.stderr, "could not cache response; %v\n", err)
+		}
+	}
+
+	if err := json.Unmarshal(cached.Body, v); err != nil {
+		return fmt.Errorf("could not decode response; %w", err)
+	}
+
+	return nil
+}
+
+// readCachedResponse returns the response stored in the file, if there is a valid one.
+func readCachedResponse(filename string) (cachedResponse, bool) {
+	var cached cachedResponse
+	bts, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return cached, false
+	}
+
+	if err := json.Unmarshal(bts, &cached); err != nil || len(cached.Body) == 0 {
+		return cachedResponse{}, false
+	}
+
+	return cached, true
+}
+
+func writeCachedResponse(filename string, cached cachedResponse) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+
+	bts, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, bts, 0600)
+}
+
+// cachedContestsApi serves the list of contests from the response cache.
+type cachedContestsApi struct {
+	interactor.ContestsApi
+	cache responseCache
+}
+
+func (c cachedContestsApi) Contests() (contests []interactor.Contest, err error) {
+	err = c.cache.getJSON("contests", "contests", &contests)
+	return
+}
+
+func (c cachedContestsApi) ContestById(contestId string) (contest interactor.Contest, err error) {
+	err = c.cache.getJSON("contests/"+contestId, "contest", &contest)
+	return
+}
+
+// cachedContestApi serves the contest and its collections from the response cache. Single objects, which are not
+// cached, and posts go to the underlying api.
+type cachedContestApi struct {
+	interactor.ContestApi
+	cache     responseCache
+	contestId string
+}
+
+// path returns the path of an endpoint of the contest.
+func (c cachedContestApi) path(endpoint string) string {
+	return "contests/" + c.contestId + "/" + endpoint
+}
+
+func (c cachedContestApi) Contests() ([]interactor.Contest, error) {
+	return cachedContestsApi{cache: c.cache}.Contests()
+}
+
+func (c cachedContestApi) ContestById(contestId string) (interactor.Contest, error) {
+	return cachedContestsApi{cache: c.cache}.ContestById(contestId)
+}
+
+func (c cachedContestApi) Contest() (interactor.Contest, error) {
+	return c.ContestById(c.contestId)
+}
+
+func (c cachedContestApi) Account() (account interactor.Account, err error) {
+	err = c.cache.getJSON(c.path("account"), "account", &account)
+	return
+}
+
+func (c cachedContestApi) Accounts() (accounts []interactor.Account, err error) {
+	err = c.cache.getJSON(c.path("accounts"), "accounts", &accounts)
+	return
+}
+
+func (c cachedContestApi) Problems() (problems []interactor.Problem, err error) {
+	err = c.cache.getJSON(c.path("problems"), "problems", &problems)
+	return
+}
+
+func (c cachedContestApi) Languages() (languages []interactor.Language, err error) {
+	err = c.cache.getJSON(c.path("languages"), "languages", &languages)
+	return
+}
+
+func (c cachedContestApi) JudgementTypes() (judgementTypes []interactor.JudgementType, err error) {
+	err = c.cache.getJSON(c.path("judgement-types"), "judgement-types", &judgementTypes)
+	return
+}
+
+func (c cachedContestApi) Teams() (teams []interactor.Team, err error) {
+	err = c.cache.getJSON(c.path("teams"), "teams", &teams)
+	return
+}
+
+func (c cachedContestApi) Organizations() (organizations []interactor.Organization, err error) {
+	err = c.cache.getJSON(c.path("organizations"), "organizations", &organizations)
+	return
+}
+
+func (c cachedContestApi) Groups() (groups []interactor.Group, err error) {
+	err = c.cache.getJSON(c.path("groups"), "groups", &groups)
+	return
+}
+
+func (c cachedContestApi) Submissions() (submissions []interactor.Submission, err error) {
+	err = c.cache.getJSON(c.path("submissions"), "submissions", &submissions)
+	return
+}
+
+func (c cachedContestApi) Judgements() (judgements []interactor.Judgement, err error) {
+	err = c.cache.getJSON(c.path("judgements"), "judgements", &judgements)
+	return
+}
+
+func (c cachedContestApi) Clarifications() (clarifications []interactor.Clarification, err error) {
+	err = c.cache.getJSON(c.path("clarifications"), "clarifications", &clarifications)
+	return
+}
+
+func (c cachedContestApi) Scoreboard() (scoreboard interactor.Scoreboard, err error) {
+	err = c.cache.getJSON(c.path("scoreboard"), "scoreboard", &scoreboard)
+	return
+}
+
+func (c cachedContestApi) State() (state interactor.State, err error) {
+	err = c.cache.getJSON(c.path("state"), "state", &state)
+	return
+}
+
+// offlineContestApi is the underlying api of a cachedContestApi in offline mode. Everything that is not served from
+// the cache fails.
+type offlineContestApi struct {
+	// ContestApi is nil, every method that is not cached is overridden below
+	interactor.ContestApi
+}
+
+func (offlineContestApi) ToContest(string) (interactor.ContestApi, error) {
+	return nil, errOffline
+}
+
+func (offlineContestApi) AccountById(string) (interactor.Account, error) {
+	return interactor.Account{}, errOffline
+}
+
+func (offlineContestApi) ProblemById(string) (interactor.Problem, error) {
+	return interactor.Problem{}, errOffline
+}
+
+func (offlineContestApi) JudgementTypeById(string) (interactor.JudgementType, error) {
+	return interactor.JudgementType{}, errOffline
+}
+
+func (offlineContestApi) SubmissionById(string) (interactor.Submission, error) {
+	return interactor.Submission{}, errOffline
+}
+
+func (offlineContestApi) JudgementById(string) (interactor.Judgement, error) {
+	return interactor.Judgement{}, errOffline
+}
+
+func (offlineContestApi) ClarificationById(string) (interactor.Clarification, error) {
+	return interactor.Clarification{}, errOffline
+}
+
+func (offlineContestApi) LanguageById(string) (interactor.Language, error) {
+	return interactor.Language{}, errOffline
+}
+
+func (offlineContestApi) GroupById(string) (interactor.Group, error) {
+	return interactor.Group{}, errOffline
+}
+
+func (offlineContestApi) OrganizationById(string) (interactor.Organization, error) {
+	return interactor.Organization{}, errOffline
+}
+
+func (offlineContestApi) TeamById(string) (interactor.Team, error) {
+	return interactor.Team{}, errOffline
+}
+
+func (offlineContestApi) GetObject(interactor.ApiType, string) (interactor.ApiType, error) {
+	return nil, errOffline
+}
+
+func (offlineContestApi) GetObjects(interactor.ApiType) ([]interactor.ApiType, error) {
+	return nil, errOffline
+}
+
+func (offlineContestApi) Submit(interactor.Submittable) (interactor.ApiType, error) {
+	return nil, errOffline
+}
+
+func (offlineContestApi) PostClarification(string, string) (interactor.Clarification, error) {
+	return interactor.Clarification{}, errOffline
+}
+
+func (offlineContestApi) PostSubmission(string, string, string, interactor.LocalFileReference) (interactor.Submission, error) {
+	return interactor.Submission{}, errOffline
+}
+
+// lazyContestApi is the underlying api of a cachedContestApi in online mode. The interactor looks up the contest when it
+// is created, which the cache already did, so it is only created when something that is not cached is needed.
+type lazyContestApi struct {
+	// ContestApi is nil, every method that is not cached is overridden below
+	interactor.ContestApi
+	connect func() (interactor.ContestApi, error)
+
+	once sync.Once
+	api  interactor.ContestApi
+	err  error
+}
+
+func (l *lazyContestApi) connected() (interactor.ContestApi, error) {
+	l.once.Do(func() {
+		l.api, l.err = l.connect()
+	})
+
+	return l.api, l.err
+}
+
+func (l *lazyContestApi) ToContest(contestId string) (interactor.ContestApi, error) {
+	api, err := l.connected()
+	if err != nil {
+		return nil, err
+	}
+
+	return api.ToContest(contestId)
+}
+
+func (l *lazyContestApi) AccountById(id string) (interactor.Account, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Account{}, err
+	}
+
+	return api.AccountById(id)
+}
+
+func (l *lazyContestApi) ProblemById(id string) (interactor.Problem, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Problem{}, err
+	}
+
+	return api.ProblemById(id)
+}
+
+func (l *lazyContestApi) JudgementTypeById(id string) (interactor.JudgementType, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.JudgementType{}, err
+	}
+
+	return api.JudgementTypeById(id)
+}
+
+func (l *lazyContestApi) SubmissionById(id string) (interactor.Submission, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Submission{}, err
+	}
+
+	return api.SubmissionById(id)
+}
+
+func (l *lazyContestApi) JudgementById(id string) (interactor.Judgement, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Judgement{}, err
+	}
+
+	return api.JudgementById(id)
+}
+
+func (l *lazyContestApi) ClarificationById(id string) (interactor.Clarification, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Clarification{}, err
+	}
+
+	return api.ClarificationById(id)
+}
+
+func (l *lazyContestApi) LanguageById(id string) (interactor.Language, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Language{}, err
+	}
+
+	return api.LanguageById(id)
+}
+
+func (l *lazyContestApi) GroupById(id string) (interactor.Group, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Group{}, err
+	}
+
+	return api.GroupById(id)
+}
+
+func (l *lazyContestApi) OrganizationById(id string) (interactor.Organization, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Organization{}, err
+	}
+
+	return api.OrganizationById(id)
+}
+
+func (l *lazyContestApi) TeamById(id string) (interactor.Team, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Team{}, err
+	}
+
+	return api.TeamById(id)
+}
+
+func (l *lazyContestApi) GetObject(apiType interactor.ApiType, id string) (interactor.ApiType, error) {
+	api, err := l.connected()
+	if err != nil {
+		return nil, err
+	}
+
+	return api.GetObject(apiType, id)
+}
+
+func (l *lazyContestApi) GetObjects(apiType interactor.ApiType) ([]interactor.ApiType, error) {
+	api, err := l.connected()
+	if err != nil {
+		return nil, err
+	}
+
+	return api.GetObjects(apiType)
+}
+
+func (l *lazyContestApi) Submit(submittable interactor.Submittable) (interactor.ApiType, error) {
+	api, err := l.connected()
+	if err != nil {
+		return nil, err
+	}
+
+	return api.Submit(submittable)
+}
+
+func (l *lazyContestApi) PostClarification(problemId, text string) (interactor.Clarification, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Clarification{}, err
+	}
+
+	return api.PostClarification(problemId, text)
+}
+
+func (l *lazyContestApi) PostSubmission(problemId, languageId, entrypoint string, files interactor.LocalFileReference) (interactor.Submission, error) {
+	api, err := l.connected()
+	if err != nil {
+		return interactor.Submission{}, err
+	}
+
+	return api.PostSubmission(problemId, languageId, entrypoint, files)
+}
@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/icpctools/cli/mockccs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCache(t *testing.T) {
+	defer func(c commandContext) { cmdCtx = c }(cmdCtx)
+
+	now := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+	cmdCtx.now = func() time.Time { return now }
+
+	requests := map[string]int{}
+	notModified := map[string]int{}
+	bodies := map[string]string{
+		"/contests/practice/problems":    `[{"id":"hello","label":"A","name":"Hello World"}]`,
+		"/contests/practice/submissions": `[{"id":"1","problem_id":"hello"}]`,
+		"/contests/practice/teams":       `[]`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		body, ok := bodies[r.URL.Path]
+		if r.URL.Path == "/contests/practice/account" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":404,"message":"Account not found"}`))
+			return
+		} else if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		etag := `"` + body + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			notModified[r.URL.Path]++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	setViper(t, "baseurl", ts.URL)
+	setViper(t, "cache.dir", t.TempDir())
+	api := cachedContestApi{cache: newResponseCache(rawApiFor("team1", "team1")), contestId: "practice"}
+
+	// Static collections are only retrieved again after their TTL
+	for i := 0; i < 2; i++ {
+		problems, err := api.Problems()
+		assert.NoError(t, err)
+		assert.Equal(t, []interactor.Problem{{Id: "hello", Label: "A", Name: "Hello World"}}, problems)
+	}
+	assert.Equal(t, 1, requests["/contests/practice/problems"])
+
+	now = now.Add(staticTTL)
+	_, err := api.Problems()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests["/contests/practice/problems"])
+	assert.Equal(t, 1, notModified["/contests/practice/problems"])
+
+	// Other endpoints are revalidated every time, as are empty collections
+	for i := 0; i < 2; i++ {
+		submissions, err := api.Submissions()
+		assert.NoError(t, err)
+		assert.Len(t, submissions, 1)
+
+		_, err = api.Teams()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, requests["/contests/practice/submissions"])
+	assert.Equal(t, 1, notModified["/contests/practice/submissions"])
+	assert.Equal(t, 2, requests["/contests/practice/teams"])
+
+	// The TTL can be configured
+	setViper(t, "cache.ttl.submissions", "1m")
+	_, err = api.Submissions()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests["/contests/practice/submissions"])
+
+	now = now.Add(time.Minute)
+	_, err = api.Submissions()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, requests["/contests/practice/submissions"])
+
+	_, err = api.Judgements()
+	assert.EqualError(t, err, "unexpected status code 404 for "+ts.URL+"/contests/practice/judgements")
+
+	_, err = api.Account()
+	assert.EqualError(t, err, "Account not found (error code 404)")
+
+	ts.Close()
+	now = now.Add(staticTTL)
+	_, err = api.Problems()
+	assert.Contains(t, err.Error(), "use --offline to use the response retrieved at")
+
+	// Offline the last responses are used regardless of their age
+	api.cache.offline = true
+	problems, err := api.Problems()
+	assert.NoError(t, err)
+	assert.Len(t, problems, 1)
+
+	_, err = api.Clarifications()
+	assert.True(t, errors.Is(err, errOffline))
+}
+
+func TestResponseCacheSkipsUnchangedWrites(t *testing.T) {
+	defer func(c commandContext) { cmdCtx = c }(cmdCtx)
+
+	start := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+	now := start
+	cmdCtx.now = func() time.Time { return now }
+
+	body := `{"running":true}`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"`+body+`"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"`+body+`"`)
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	setViper(t, "baseurl", ts.URL)
+	setViper(t, "cache.dir", t.TempDir())
+	cache := newResponseCache(rawApiFor("team1", "team1"))
+	retrieved := func() time.Time {
+		cached, _ := readCachedResponse(cache.filename("contests/practice/state"))
+		return cached.Retrieved
+	}
+
+	var v map[string]interface{}
+	assert.NoError(t, cache.getJSON("contests/practice/state", "state", &v))
+	assert.Equal(t, start, retrieved())
+
+	// Revalidating an endpoint without a TTL does not write the same response again
+	now = start.Add(time.Minute)
+	assert.NoError(t, cache.getJSON("contests/practice/state", "state", &v))
+	assert.Equal(t, start, retrieved())
+
+	body = `{"running":false}`
+	assert.NoError(t, cache.getJSON("contests/practice/state", "state", &v))
+	assert.Equal(t, now, retrieved())
+	assert.Equal(t, false, v["running"])
+}
+
+func TestConnectContestApi(t *testing.T) {
+	server := mockccs.New(mockccs.Package{
+		Contest:  mockccs.Contest{Id: "practice"},
+		Problems: []mockccs.Problem{{Id: "hello", Label: "A"}},
+	})
+	requests := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		server.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	setViper(t, "baseurl", ts.URL)
+	setViper(t, "username", "team1")
+	setViper(t, "password", "team1")
+	setViper(t, "contest", "practice")
+	setViper(t, "cache.dir", t.TempDir())
+
+	// The contest is looked up once and then served from the cache
+	for i := 0; i < 2; i++ {
+		api, err := connectContestApi()
+		assert.NoError(t, err)
+
+		contest, err := api.Contest()
+		assert.NoError(t, err)
+		assert.Equal(t, "practice", contest.Id)
+	}
+	assert.Equal(t, 1, requests["/contests/practice"])
+
+	// The interactor is only created when something is not cached
+	api, err := connectContestApi()
+	assert.NoError(t, err)
+	problem, err := api.ProblemById("hello")
+	assert.NoError(t, err)
+	assert.Equal(t, "A", problem.Label)
+	assert.Equal(t, 2, requests["/contests/practice"])
+
+	setViper(t, "contest", "unknown")
+	_, err = connectContestApi()
+	assert.Error(t, err)
+}
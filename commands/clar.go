@@ -2,20 +2,33 @@ package commands
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"unicode"
 
+	interactor "github.com/icpctools/api-interactor"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var clarCommand = &cobra.Command{
-	Use:     "clar",
-	Short:   "List clarifications",
+	Use:   "clar",
+	Short: "List clarifications",
+	Long: `List clarifications
+
+Replies are shown below the clarification they answer. Clarifications that were not listed before are marked as new,
+use --unread to only show those.`,
 	Args:    cobra.NoArgs,
 	RunE:    fetchClars,
 	PreRunE: configHelper("baseurl"),
 }
 
+// threadedClarification is a clarification with the number of clarifications it is a (nested) reply to.
+type threadedClarification struct {
+	interactor.Clarification
+	depth int
+}
+
 func fetchClars(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 	api, err := contestApi()
@@ -23,19 +36,54 @@ func fetchClars(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not connect to the server; %w", err)
 	}
 
+	contest, err := api.Contest()
+	if err != nil {
+		return fmt.Errorf("could not get contest; %w", err)
+	}
+
 	clars, err := api.Clarifications()
 
 	if err != nil {
 		return fmt.Errorf("could not retrieve clarifications; %w", err)
 	}
 
+	problems, err := api.Problems()
+	if err != nil {
+		return fmt.Errorf("could not get problems; %w", err)
+	}
+
+	key := stateKey(viper.GetString("baseurl"), contest.Id)
+	state, err := loadContestState(key)
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, id := range state.SeenClarifications {
+		seen[id] = true
+	}
+
+	if clarUnread {
+		var unread []interactor.Clarification
+		for _, o := range clars {
+			if !seen[o.Id] {
+				unread = append(unread, o)
+			}
+		}
+		clars = unread
+	}
+
 	// output
-	fmt.Printf("\nClarifications (%d):\n", len(clars))
+	if clarUnread {
+		printBanner("\nUnread clarifications (%d):\n", len(clars))
+	} else {
+		printBanner("\nClarifications (%d):\n", len(clars))
+	}
 
 	var table = Table{}
-	table.Header = []string{"Time", "Type", "Problem", "Text"}
-	table.Align = []int{ALIGN_RIGHT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_LEFT}
-	for _, o := range clars {
+	table.Header = []string{"New", "Time", "Type", "Problem", "Text"}
+	table.Align = []int{ALIGN_LEFT, ALIGN_RIGHT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_LEFT}
+	for _, o := range threadClarifications(clars) {
 		var kind = ""
 		if o.FromTeamId == "" && o.ToTeamId == "" {
 			kind = "Broadcast message from jury"
@@ -46,11 +94,6 @@ func fetchClars(cmd *cobra.Command, args []string) error {
 		}
 		var prb = ""
 		if o.ProblemId != "" {
-			problems, err := api.Problems()
-			if err != nil {
-				return fmt.Errorf("could not get problems; %w", err)
-			}
-
 			problem, hasProblem := problemSet(problems).byId(o.ProblemId)
 			if !hasProblem {
 				prb = "unknown problem"
@@ -58,8 +101,22 @@ func fetchClars(cmd *cobra.Command, args []string) error {
 				prb = fmt.Sprintf("%s: %s", problem.Label, problem.Name)
 			}
 		}
-		var first = true
+		var isNew = ""
+		if !seen[o.Id] {
+			isNew = "*"
+		}
 		var time = fmt.Sprintf("%v", o.ContestTime)
+		if machineOutput() {
+			// Tools get the full text in a single row rather than one row per line
+			table.appendRow([]string{isNew, time, kind, prb, o.Text})
+			continue
+		}
+
+		if o.depth > 0 {
+			kind = strings.Repeat("  ", o.depth-1) + "↳ " + kind
+		}
+
+		var first = true
 		lines := strings.Split(o.Text, "\n")
 		for _, s := range lines {
 			s = strings.TrimFunc(s, func(r rune) bool {
@@ -67,13 +124,71 @@ func fetchClars(cmd *cobra.Command, args []string) error {
 			})
 			if first {
 				first = false
-				table.appendRow([]string{time, kind, prb, s})
+				table.appendRow([]string{isNew, time, kind, prb, s})
 			} else {
-				table.appendRow([]string{"", "", "", s})
+				table.appendRow([]string{"", "", "", "", s})
 			}
 		}
 	}
 	table.print()
 
-	return nil
+	// Everything that was shown has now been seen
+	for _, o := range clars {
+		if !seen[o.Id] {
+			seen[o.Id] = true
+			state.SeenClarifications = append(state.SeenClarifications, o.Id)
+		}
+	}
+
+	return saveContestState(key, state)
+}
+
+// threadClarifications orders clarifications by time, with the replies to a clarification directly after it. Replies
+// to clarifications that are not in the list are shown as if they were not a reply.
+func threadClarifications(clars []interactor.Clarification) []threadedClarification {
+	sorted := append([]interactor.Clarification(nil), clars...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ContestTime < sorted[j].ContestTime
+	})
+
+	ids := map[string]bool{}
+	for _, c := range sorted {
+		ids[c.Id] = true
+	}
+
+	replies := map[string][]interactor.Clarification{}
+	var roots []interactor.Clarification
+	for _, c := range sorted {
+		if c.ReplyToId != "" && c.ReplyToId != c.Id && ids[c.ReplyToId] {
+			replies[c.ReplyToId] = append(replies[c.ReplyToId], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var threaded []threadedClarification
+	added := map[string]bool{}
+	var add func(c interactor.Clarification, depth int)
+	add = func(c interactor.Clarification, depth int) {
+		if added[c.Id] {
+			return
+		}
+		added[c.Id] = true
+
+		threaded = append(threaded, threadedClarification{Clarification: c, depth: depth})
+		for _, reply := range replies[c.Id] {
+			add(reply, depth+1)
+		}
+	}
+
+	for _, c := range roots {
+		add(c, 0)
+	}
+
+	// Clarifications in a reply cycle have no root, so they are added at the top level
+	for _, c := range sorted {
+		add(c, 0)
+	}
+
+	return threaded
 }
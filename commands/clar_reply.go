@@ -0,0 +1,211 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/spf13/cobra"
+)
+
+// juryAccountTypes are the account types that may answer clarifications
+var juryAccountTypes = []string{"judge", "admin"}
+
+var clarReplyCommand = &cobra.Command{
+	Use:   "reply [clar-id] [text]",
+	Short: "Answer a clarification (judges and admins only)",
+	Long: `Answer a clarification (judges and admins only)
+
+The answer is sent to the team that asked the clarification, unless it is broadcast to all teams using --broadcast or
+sent to another team using --to-team.`,
+	Args:    cobra.ExactArgs(2),
+	RunE:    replyClarification,
+	PreRunE: configHelper("baseurl"),
+}
+
+var clarPendingCommand = &cobra.Command{
+	Use:     "pending",
+	Short:   "List unanswered clarifications of teams (judges and admins only)",
+	Args:    cobra.NoArgs,
+	RunE:    fetchPendingClars,
+	PreRunE: configHelper("baseurl"),
+}
+
+func replyClarification(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if clarBroadcast && clarToTeam != "" {
+		return errors.New("--broadcast and --to-team can not be used together")
+	}
+
+	api, err := contestApi()
+	if err != nil {
+		return fmt.Errorf("could not connect to the server; %w", err)
+	}
+
+	if err := requireJuryAccount(api); err != nil {
+		return err
+	}
+
+	clars, err := api.Clarifications()
+	if err != nil {
+		return fmt.Errorf("could not retrieve clarifications; %w", err)
+	}
+
+	var question interactor.Clarification
+	for _, c := range clars {
+		if c.Id == args[0] {
+			question = c
+		}
+	}
+	if question.Id == "" {
+		return fmt.Errorf("unknown clarification %s", args[0])
+	}
+
+	reply := interactor.Clarification{
+		ReplyToId: question.Id,
+		ProblemId: question.ProblemId,
+		ToTeamId:  question.FromTeamId,
+		Text:      args[1],
+	}
+	if clarBroadcast {
+		reply.ToTeamId = ""
+	} else if clarToTeam != "" {
+		reply.ToTeamId = clarToTeam
+	}
+
+	if !force {
+		fmt.Fprintln(cmdCtx.stdout, "About to answer clarification:")
+		fmt.Fprintf(cmdCtx.stdout, "  question: %s\n", strings.SplitN(question.Text, "\n", 2)[0])
+		if reply.ToTeamId == "" {
+			fmt.Fprintln(cmdCtx.stdout, "  to:       all teams (broadcast)")
+		} else {
+			fmt.Fprintf(cmdCtx.stdout, "  to:       team %s\n", reply.ToTeamId)
+		}
+		fmt.Fprintln(cmdCtx.stdout, "  text:")
+		for _, line := range strings.Split(reply.Text, "\n") {
+			fmt.Fprintf(cmdCtx.stdout, "    %s\n", line)
+		}
+
+		if !cmdCtx.prompter.YN("Do you want to send this answer?", true) {
+			return errors.New("answer aborted by user")
+		}
+	}
+
+	obj, err := api.Submit(reply)
+	if err != nil {
+		return fmt.Errorf("could not post answer: %w", err)
+	}
+
+	clar, ok := obj.(interactor.Clarification)
+	if !ok {
+		return fmt.Errorf("expected clarification, got: %T", obj)
+	}
+
+	_, err = fmt.Fprintf(cmdCtx.stdout, "Answer accepted at %s (%s)\n", clar.ContestTime, clar.Id)
+	return err
+}
+
+func fetchPendingClars(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	api, err := contestApi()
+	if err != nil {
+		return fmt.Errorf("could not connect to the server; %w", err)
+	}
+
+	if err := requireJuryAccount(api); err != nil {
+		return err
+	}
+
+	clars, err := api.Clarifications()
+	if err != nil {
+		return fmt.Errorf("could not retrieve clarifications; %w", err)
+	}
+
+	problems, err := api.Problems()
+	if err != nil {
+		return fmt.Errorf("could not get problems; %w", err)
+	}
+
+	teams, err := api.Teams()
+	if err != nil {
+		return fmt.Errorf("could not get teams; %w", err)
+	}
+
+	pending := pendingClarifications(clars)
+	printBanner("\nPending clarifications (%d):\n", len(pending))
+
+	var table = Table{}
+	table.Header = []string{"Id", "Time", "Team", "Problem", "Text"}
+	table.Align = []int{ALIGN_LEFT, ALIGN_RIGHT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_LEFT}
+	for _, c := range pending {
+		team := c.FromTeamId
+		if t, ok := teamSet(teams).byId(c.FromTeamId); ok {
+			team = fmt.Sprintf("%s: %s", t.Id, t.Name)
+		}
+
+		var prb = ""
+		if problem, hasProblem := problemSet(problems).byId(c.ProblemId); c.ProblemId != "" && hasProblem {
+			prb = problem.Label
+		}
+
+		text := c.Text
+		if !machineOutput() {
+			text = strings.SplitN(text, "\n", 2)[0]
+		}
+		table.appendRow([]string{c.Id, fmt.Sprintf("%v", c.ContestTime), team, prb, text})
+	}
+	table.print()
+
+	return nil
+}
+
+// requireJuryAccount returns an error when the account does not have the rights to answer clarifications. Servers
+// that do not report the account type are given the benefit of the doubt, unless the account belongs to a team.
+func requireJuryAccount(api interactor.ContestApi) error {
+	account, err := api.Account()
+	if err != nil {
+		return fmt.Errorf("could not find user account; %w", err)
+	}
+
+	for _, t := range juryAccountTypes {
+		if strings.EqualFold(account.Type, t) {
+			return nil
+		}
+	}
+
+	if account.Type == "" && account.TeamId == "" {
+		return nil
+	}
+
+	role := account.Type
+	if role == "" {
+		role = "team"
+	}
+
+	return fmt.Errorf("account %s is a %s account, answering clarifications requires a %s account", account.Username, role, strings.Join(juryAccountTypes, " or "))
+}
+
+// pendingClarifications returns the clarifications sent by teams that have not been answered, oldest first.
+func pendingClarifications(clars []interactor.Clarification) []interactor.Clarification {
+	answered := map[string]bool{}
+	for _, c := range clars {
+		if c.ReplyToId != "" {
+			answered[c.ReplyToId] = true
+		}
+	}
+
+	var pending []interactor.Clarification
+	for _, c := range clars {
+		if c.FromTeamId != "" && !answered[c.Id] {
+			pending = append(pending, c)
+		}
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].ContestTime < pending[j].ContestTime
+	})
+
+	return pending
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+// accountApi is a ContestApi that only knows the account it is logged in with
+type accountApi struct {
+	interactor.ContestApi
+	account interactor.Account
+}
+
+func (a accountApi) Account() (interactor.Account, error) {
+	return a.account, nil
+}
+
+func TestPendingClarifications(t *testing.T) {
+	at := func(minutes int) interactor.ApiRelTime {
+		return interactor.ApiRelTime(time.Duration(minutes) * time.Minute)
+	}
+
+	clars := []interactor.Clarification{
+		{Id: "q2", FromTeamId: "t2", ContestTime: at(20)},
+		{Id: "q1", FromTeamId: "t1", ContestTime: at(10)},
+		{Id: "a1", ToTeamId: "t1", ReplyToId: "q1", ContestTime: at(15)},
+		{Id: "b1", ContestTime: at(5)},
+		{Id: "q3", FromTeamId: "t3", ContestTime: at(1)},
+	}
+
+	var ids []string
+	for _, c := range pendingClarifications(clars) {
+		ids = append(ids, c.Id)
+	}
+	assert.Equal(t, []string{"q3", "q2"}, ids)
+}
+
+func TestRequireJuryAccount(t *testing.T) {
+	tests := []struct {
+		account interactor.Account
+		allowed bool
+	}{
+		{interactor.Account{Username: "judge1", Type: "judge"}, true},
+		{interactor.Account{Username: "admin", Type: "admin"}, true},
+		{interactor.Account{Username: "team1", Type: "team", TeamId: "1"}, false},
+		{interactor.Account{Username: "team1", TeamId: "1"}, false},
+		{interactor.Account{Username: "unknown"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.account.Username+"/"+tt.account.Type, func(t *testing.T) {
+			err := requireJuryAccount(accountApi{account: tt.account})
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
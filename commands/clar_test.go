@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreadClarifications(t *testing.T) {
+	clar := func(id, replyTo string, minutes int) interactor.Clarification {
+		return interactor.Clarification{Id: id, ReplyToId: replyTo, ContestTime: interactor.ApiRelTime(time.Duration(minutes) * time.Minute)}
+	}
+
+	tests := []struct {
+		name     string
+		clars    []interactor.Clarification
+		expected []string
+	}{
+		{
+			name:     "replies follow their question",
+			clars:    []interactor.Clarification{clar("q1", "", 10), clar("b1", "", 15), clar("q2", "", 20), clar("a2", "q2", 25), clar("a1", "q1", 30), clar("f1", "a1", 35)},
+			expected: []string{"q1:0", "a1:1", "f1:2", "b1:0", "q2:0", "a2:1"},
+		},
+		{
+			name:     "replies to unknown clarifications are top level",
+			clars:    []interactor.Clarification{clar("a1", "q1", 30), clar("q2", "", 20)},
+			expected: []string{"q2:0", "a1:0"},
+		},
+		{
+			name:     "reply cycles are not lost",
+			clars:    []interactor.Clarification{clar("x", "y", 10), clar("y", "x", 20)},
+			expected: []string{"x:0", "y:1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range threadClarifications(tt.clars) {
+				got = append(got, fmt.Sprintf("%s:%d", c.Id, c.depth))
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+var completionCommand = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Generate the shell completion script",
+	Long: `Generate the shell completion script
+
+Load the completions in the current shell with e.g.:
+
+  source <(contest completion bash)
+  source <(contest completion zsh)
+  contest completion fish | source
+  contest completion powershell | Out-String | Invoke-Expression
+
+To load them in every session, add that line to ~/.bashrc, ~/.zshrc, ~/.config/fish/config.fish or the PowerShell
+profile. Problem labels, language, contest, submission and clarification ids are completed from the responses earlier
+commands retrieved, so completion never waits for the server; run e.g. 'contest problem' to refresh them.`,
+	Args:                  cobra.ExactValidArgs(1),
+	ValidArgs:             completionShells,
+	DisableFlagsInUseLine: true,
+	RunE:                  generateCompletion,
+}
+
+func generateCompletion(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	switch args[0] {
+	case "bash":
+		return rootCommand.GenBashCompletionV2(cmdCtx.stdout, true)
+	case "zsh":
+		return rootCommand.GenZshCompletion(cmdCtx.stdout)
+	case "fish":
+		return rootCommand.GenFishCompletion(cmdCtx.stdout, true)
+	case "powershell":
+		return rootCommand.GenPowerShellCompletionWithDesc(cmdCtx.stdout)
+	}
+
+	return fmt.Errorf("unknown shell '%s', expected one of: %s", args[0], strings.Join(completionShells, ", "))
+}
+
+// completionCache returns the response cache of the configured server in offline mode, as completion must not wait for
+// the network. The password is not needed to find the cached responses, so the credential store is never asked.
+func completionCache() (responseCache, bool) {
+	if err := loadSettings(); err != nil {
+		return responseCache{}, false
+	}
+
+	baseUrl := viper.GetString("baseurl")
+	if baseUrl == "" {
+		return responseCache{}, false
+	}
+
+	user := viper.GetString("username")
+	if login, _, found := netrcCredentials(baseUrl); found && (user == "" || user == login) {
+		user = login
+	}
+
+	cache := newResponseCache(rawApiFor(user, ""))
+	cache.offline = true
+
+	return cache, true
+}
+
+// completionApi returns the cached responses of the configured contest, or the best one of the cached contests.
+func completionApi() (cachedContestApi, bool) {
+	cache, ok := completionCache()
+	if !ok {
+		return cachedContestApi{}, false
+	}
+
+	contest := viper.GetString("contest")
+	if contest == "" {
+		contests, err := cachedContestsApi{cache: cache}.Contests()
+		if err != nil {
+			return cachedContestApi{}, false
+		}
+
+		best, err := contestSet(contests).bestContest()
+		if err != nil {
+			return cachedContestApi{}, false
+		}
+		contest = best.Id
+	}
+
+	return cachedContestApi{ContestApi: offlineContestApi{}, cache: cache, contestId: contest}, true
+}
+
+// completions returns the candidates starting with toComplete, with their description after a tab.
+func completions(candidates map[string]string, toComplete string) []string {
+	var values []string
+	for value, description := range candidates {
+		if !strings.HasPrefix(strings.ToLower(value), strings.ToLower(toComplete)) {
+			continue
+		}
+
+		if description != "" {
+			value += "\t" + description
+		}
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	return values
+}
+
+// staticCompletion completes the given values, which do not depend on the server.
+func staticCompletion(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		candidates := map[string]string{}
+		for _, v := range values {
+			candidates[v] = ""
+		}
+
+		return completions(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeProblems completes problem labels, which every command accepts in place of problem ids.
+func completeProblems(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	api, ok := completionApi()
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	problems, err := api.Problems()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	candidates := map[string]string{}
+	for _, p := range problems {
+		label := p.Label
+		if label == "" {
+			label = p.Id
+		}
+		candidates[label] = p.Name
+	}
+
+	return completions(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProblemArgs completes problem labels that were not given yet.
+func completeProblemArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	values, directive := completeProblems(cmd, args, toComplete)
+
+	var remaining []string
+	for _, v := range values {
+		given := false
+		for _, arg := range args {
+			given = given || strings.EqualFold(strings.SplitN(v, "\t", 2)[0], arg)
+		}
+		if !given {
+			remaining = append(remaining, v)
+		}
+	}
+
+	return remaining, directive
+}
+
+func completeLanguages(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	api, ok := completionApi()
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	languages, err := api.Languages()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	candidates := map[string]string{}
+	for _, l := range languages {
+		candidates[l.Id] = l.Name
+	}
+
+	return completions(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeJudgementTypes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	api, ok := completionApi()
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	judgementTypes, err := api.JudgementTypes()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	candidates := map[string]string{}
+	for _, jt := range judgementTypes {
+		candidates[jt.Id] = jt.Name
+	}
+
+	return completions(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeContests(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cache, ok := completionCache()
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	contests, err := cachedContestsApi{cache: cache}.Contests()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	candidates := map[string]string{}
+	for _, c := range contests {
+		candidates[c.Id] = c.Name
+	}
+
+	return completions(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSubmissions completes the ids of the submissions, described by their problem and contest time.
+func completeSubmissions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	api, ok := completionApi()
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	submissions, err := api.Submissions()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	problems, _ := api.Problems()
+	candidates := map[string]string{}
+	for _, s := range submissions {
+		problem := s.ProblemId
+		if p, ok := problemSet(problems).byId(s.ProblemId); ok {
+			problem = p.Label
+		}
+		candidates[s.Id] = fmt.Sprintf("%s at %v", problem, s.ContestTime)
+	}
+
+	return completions(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePendingClarifications completes the ids of the clarifications that were not answered yet.
+func completePendingClarifications(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	api, ok := completionApi()
+	if !ok {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	clars, err := api.Clarifications()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	candidates := map[string]string{}
+	for _, c := range pendingClarifications(clars) {
+		candidates[c.Id] = strings.SplitN(c.Text, "\n", 2)[0]
+	}
+
+	return completions(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if loadSettings() != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	candidates := map[string]string{}
+	for name := range profiles() {
+		candidates[name] = stringSetting(profileSettings(name), "baseurl")
+	}
+
+	return completions(candidates, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// registerFlagCompletion registers the completion of the flag on every command that has it.
+func registerFlagCompletion(flag string, f func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective), commands ...*cobra.Command) {
+	for _, cmd := range commands {
+		if err := cmd.RegisterFlagCompletionFunc(flag, f); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// completeFirstArg only completes the first argument with f.
+func completeFirstArg(f func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return f(cmd, args, toComplete)
+	}
+}
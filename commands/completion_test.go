@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletions(t *testing.T) {
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func(name string) { profileName = name }(profileName)
+	profileName = ""
+	clearViperConfig(t)
+
+	setViper(t, "baseurl", "https://example.org/api")
+	setViper(t, "username", "team1")
+	setViper(t, "cache.dir", t.TempDir())
+
+	// Nothing was retrieved before, so nothing can be completed
+	values, directive := completeProblems(submitCommand, nil, "")
+	assert.Empty(t, values)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+
+	cache := newResponseCache(rawApiFor("team1", ""))
+	for path, body := range map[string]string{
+		"contests": `[{"id":"practice","name":"Practice"}]`,
+		"contests/practice/problems": `[{"id":"hello","label":"A","name":"Hello World"},` +
+			`{"id":"sum","label":"B","name":"Sum"},{"id":"bits","label":"BB","name":"Bits"}]`,
+		"contests/practice/languages":   `[{"id":"cpp","name":"C++"},{"id":"c","name":"C"},{"id":"python3","name":"Python 3"}]`,
+		"contests/practice/submissions": `[{"id":"7","problem_id":"sum","contest_time":"0:12:00.000"}]`,
+		"contests/practice/clarifications": `[{"id":"q1","from_team_id":"1","text":"Is n > 0?\nThanks"},` +
+			`{"id":"q2","from_team_id":"1","text":"Answered"},{"id":"a2","reply_to_id":"q2","to_team_id":"1","text":"Yes"}]`,
+	} {
+		assert.NoError(t, writeCachedResponse(cache.filename(path), cachedResponse{Body: []byte(body)}))
+	}
+
+	tests := []struct {
+		name       string
+		complete   func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)
+		args       []string
+		toComplete string
+		expected   []string
+	}{
+		{"problems", completeProblems, nil, "b", []string{"B\tSum", "BB\tBits"}},
+		{"problem arguments", completeProblemArgs, []string{"a"}, "", []string{"B\tSum", "BB\tBits"}},
+		{"languages", completeLanguages, nil, "c", []string{"c\tC", "cpp\tC++"}},
+		{"contests", completeContests, nil, "", []string{"practice\tPractice"}},
+		{"submissions", completeSubmissions, nil, "", []string{"7\tB at 12m"}},
+		{"pending clarifications", completePendingClarifications, nil, "", []string{"q1\tIs n > 0?"}},
+		{"first argument only", completeFirstArg(completeSubmissions), []string{"7"}, "", nil},
+		{"output formats", staticCompletion(outputFormats...), nil, "y", []string{"yaml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, directive := tt.complete(submitCommand, tt.args, tt.toComplete)
+			assert.Equal(t, tt.expected, values)
+			assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+		})
+	}
+}
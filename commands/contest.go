@@ -1,9 +1,11 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"sort"
-	"time"
+	"strconv"
+	"strings"
 
 	interactor "github.com/icpctools/api-interactor"
 	"github.com/spf13/cobra"
@@ -30,7 +32,7 @@ func outputContest(table *Table, c interactor.Contest) {
 			row = append(row, "", duration, "Not scheduled")
 		}
 	} else {
-		now := time.Now()
+		now := cmdCtx.now()
 		if c.StartTime.Time().After(now) {
 			row = append(row, fmt.Sprintf("%v", c.StartTime), duration, "Scheduled")
 		} else if (c.StartTime.Time().Add(c.Duration.Duration())).After(now) {
@@ -73,7 +75,7 @@ func fetchContests(cmd *cobra.Command, args []string) error {
 		})
 
 		// output
-		fmt.Printf("\nContests (%d):\n", len(c))
+		printBanner("\nContests (%d):\n", len(c))
 		for _, o := range c {
 			outputContest(&table, o)
 		}
@@ -82,3 +84,52 @@ func fetchContests(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// canPickContest returns whether the user can be asked to pick a contest: both stdin and stdout must be a terminal,
+// and the output must be meant for humans.
+func canPickContest() bool {
+	return stdinIsTerminal() && isTerminal(cmdCtx.stdout) && !machineOutput()
+}
+
+// pickContest asks the user to pick one of the candidate contests, and offers to save the choice in the active profile.
+func pickContest(ambiguous ambiguousContestError) (interactor.Contest, error) {
+	candidates := ambiguous.candidates
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].StartTime.Time().Before(candidates[j].StartTime.Time())
+	})
+
+	var table = Table{}
+	table.Header = []string{"#", "Id", "Name", "Start Time", "Length", "Status"}
+	table.Align = []int{ALIGN_RIGHT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_RIGHT, ALIGN_LEFT}
+	for i, c := range candidates {
+		outputContest(&table, c)
+		table.Rows[i] = append(rowStr{strconv.Itoa(i + 1)}, table.Rows[i]...)
+	}
+
+	// The candidates are not part of the output of the command
+	fmt.Fprintf(cmdCtx.stderr, "Could not pick a contest automatically: %s.\n\n", ambiguous.reason)
+	table.writeText(cmdCtx.stderr)
+	fmt.Fprintln(cmdCtx.stderr)
+
+	var picked interactor.Contest
+	for picked.Id == "" {
+		answer := strings.TrimSpace(cmdCtx.prompter.Prompt(fmt.Sprintf("Which contest do you want to use? [1-%d]", len(candidates)), ""))
+		if answer == "" {
+			return picked, errors.New("no contest picked")
+		}
+
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(candidates) {
+			picked = candidates[n-1]
+		} else {
+			fmt.Fprintf(cmdCtx.stderr, "Please enter a number between 1 and %d\n", len(candidates))
+		}
+	}
+
+	if cmdCtx.prompter.YN(fmt.Sprintf("Do you want to use contest %s by default in profile %s?", picked.Id, activeProfile), false) {
+		if err := saveContestId(picked.Id); err != nil {
+			return picked, err
+		}
+	}
+
+	return picked, nil
+}
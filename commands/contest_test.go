@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBestContestAmbiguous(t *testing.T) {
+	running := interactor.ApiTime(time.Now().Add(-time.Hour))
+	contests := contestSet{
+		{Id: "practice", StartTime: running, Duration: interactor.ApiRelTime(5 * time.Hour)},
+		{Id: "finals", StartTime: running, Duration: interactor.ApiRelTime(5 * time.Hour)},
+		{Id: "old", StartTime: interactor.ApiTime(time.Now().Add(-48 * time.Hour)), Duration: interactor.ApiRelTime(5 * time.Hour)},
+	}
+
+	_, err := contests.bestContest()
+	var ambiguous ambiguousContestError
+	assert.True(t, errors.As(err, &ambiguous))
+	assert.Len(t, ambiguous.candidates, 2)
+	assert.EqualError(t, err, "more than one contest is currently running, use --contest or set id with one of: practice, finals")
+
+	_, err = contestSet{{Id: "a"}, {Id: "b"}}.bestContest()
+	assert.EqualError(t, err, "there are no scheduled contests, use --contest or set id with one of: a, b")
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/Songmu/prompter"
+	interactor "github.com/icpctools/api-interactor"
+)
+
+// userPrompter asks the user questions, normally on the terminal.
+type userPrompter interface {
+	// YN asks a yes or no question, returning def when nothing is answered
+	YN(question string, def bool) bool
+	// Prompt asks for a line of text, returning def when nothing is answered
+	Prompt(message, def string) string
+	// Password asks for a secret without echoing it
+	Password(message string) string
+}
+
+// terminalPrompter prompts on the terminal.
+type terminalPrompter struct{}
+
+func (terminalPrompter) YN(question string, def bool) bool {
+	return prompter.YN(question, def)
+}
+
+func (terminalPrompter) Prompt(message, def string) string {
+	return prompter.Prompt(message, def)
+}
+
+func (terminalPrompter) Password(message string) string {
+	return prompter.Password(message)
+}
+
+// commandContext holds everything the commands use to talk to the outside world, so tests can replace it.
+type commandContext struct {
+	// contestApi connects to the contest currently configured
+	contestApi func() (interactor.ContestApi, error)
+	// contestsApi connects to the server currently configured, without selecting a contest
+	contestsApi func() (interactor.ContestsApi, error)
+
+	stdout io.Writer
+	stderr io.Writer
+
+	// now returns the current time
+	now func() time.Time
+
+	prompter userPrompter
+}
+
+// defaultCommandContext returns the context the commands run with: the configured server, the standard streams, the
+// wall clock and the terminal.
+func defaultCommandContext() commandContext {
+	return commandContext{
+		contestApi:  connectContestApi,
+		contestsApi: connectContestsApi,
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		now:         time.Now,
+		prompter:    terminalPrompter{},
+	}
+}
+
+// cmdCtx is the context of the command being run. It is set up in init, as the functions it refers to use it too.
+var cmdCtx commandContext
+
+func init() {
+	cmdCtx = defaultCommandContext()
+}
+
+// contestApi connects to the contest currently configured.
+func contestApi() (interactor.ContestApi, error) {
+	return cmdCtx.contestApi()
+}
+
+// contestsApi connects to the server currently configured.
+func contestsApi() (interactor.ContestsApi, error) {
+	return cmdCtx.contestsApi()
+}
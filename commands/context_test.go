@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/icpctools/cli/mockccs"
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// testdataDir is absolute, as some tests change the working directory
+var testdataDir, _ = filepath.Abs("testdata")
+
+// scriptedPrompter answers questions from a script, echoing them like the terminal would.
+type scriptedPrompter struct {
+	out     *bytes.Buffer
+	answers []string
+}
+
+func (p *scriptedPrompter) answer(question string) string {
+	var answer string
+	if len(p.answers) > 0 {
+		answer, p.answers = p.answers[0], p.answers[1:]
+	}
+	fmt.Fprintf(p.out, "%s %s\n", question, answer)
+
+	return answer
+}
+
+func (p *scriptedPrompter) YN(question string, def bool) bool {
+	switch p.answer(question + " (y/n)") {
+	case "y":
+		return true
+	case "n":
+		return false
+	default:
+		return def
+	}
+}
+
+func (p *scriptedPrompter) Prompt(message, def string) string {
+	if answer := p.answer(message); answer != "" {
+		return answer
+	}
+	return def
+}
+
+func (p *scriptedPrompter) Password(message string) string {
+	return p.answer(message)
+}
+
+// testContext replaces the command context for the duration of the test with one connecting to the server as the
+// given user, with the clock at now. The output and errors of the commands are collected in the returned buffers.
+func testContext(t *testing.T, url, user string, now time.Time, answers ...string) (stdout, stderr *bytes.Buffer) {
+	stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+
+	previous := cmdCtx
+	t.Cleanup(func() { cmdCtx = previous })
+
+	cmdCtx = commandContext{
+		contestApi: func() (interactor.ContestApi, error) {
+			return interactor.ContestInteractor(url, user, user, "practice", false)
+		},
+		contestsApi: func() (interactor.ContestsApi, error) {
+			return interactor.ContestsInteractor(url, user, user, false)
+		},
+		stdout:   stdout,
+		stderr:   stderr,
+		now:      func() time.Time { return now },
+		prompter: &scriptedPrompter{out: stdout, answers: answers},
+	}
+
+	return stdout, stderr
+}
+
+// assertGolden compares the output with testdata/<name>.golden, or updates the file when -update is given.
+func assertGolden(t *testing.T, name string, actual []byte) {
+	filename := filepath.Join(testdataDir, name+".golden")
+	if *updateGolden {
+		assert.NoError(t, os.MkdirAll(testdataDir, 0755))
+		assert.NoError(t, ioutil.WriteFile(filename, actual, 0644))
+		return
+	}
+
+	expected, err := ioutil.ReadFile(filename)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(expected), string(actual))
+	}
+}
+
+func TestCommandOutput(t *testing.T) {
+	defer func(l *time.Location) { time.Local = l }(time.Local)
+	defer func(f, w bool, p string) { force, wait, problemId = f, w, p }(force, wait, problemId)
+	defer func(format string) { outputFormat = format }(outputFormat)
+	time.Local = time.UTC
+
+	start := time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)
+	now := start.Add(90 * time.Minute)
+
+	server := mockccs.New(mockccs.Package{
+		Contest: mockccs.Contest{
+			Id:                       "practice",
+			Name:                     "Practice",
+			StartTime:                &start,
+			Duration:                 mockccs.RelTime(5 * time.Hour),
+			ScoreboardFreezeDuration: mockccs.RelTime(time.Hour),
+			PenaltyTime:              20,
+		},
+		Problems: []mockccs.Problem{{Id: "hello", Label: "A", Name: "Hello World"}, {Id: "sum", Label: "B", Name: "Sum"}},
+		Teams:    []mockccs.Team{{Id: "1", Name: "Alpha"}, {Id: "2", Name: "Beta"}},
+	})
+	server.Verdicts = map[string]string{"B": "WA"}
+	server.Now = func() time.Time { return now }
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "hello.py"), []byte("print('Hello')"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sum.py"), []byte("print(1)"), 0644))
+
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	// Every step depends on the submissions and clarifications of the steps before it
+	steps := []struct {
+		name    string
+		user    string
+		format  string
+		answers []string
+		run     func() error
+	}{
+		{name: "contest", run: func() error { return fetchContests(contestCommand, nil) }},
+		{name: "contest_json", format: outputJSON, run: func() error { return fetchContests(contestCommand, nil) }},
+		{name: "problem", run: func() error { return fetchProblems(problemCommand, nil) }},
+		{name: "status", run: func() error { return status(statusCommand, nil) }},
+		{name: "submit", answers: []string{"y"}, run: func() error {
+			wait = true
+			defer func() { wait = false }()
+			return submit(submitCommand, []string{"hello.py"})
+		}},
+		{name: "submit_aborted", answers: []string{"n"}, run: func() error {
+			return ignoreError(submit(submitCommand, []string{"sum.py"}), "submission aborted by user")
+		}},
+		{name: "submit_forced", run: func() error {
+			force = true
+			defer func() { force = false }()
+			return submit(submitCommand, []string{"sum.py"})
+		}},
+		{name: "submissions", run: func() error { return submissions(submissionsCommand, nil) }},
+		{name: "submissions_csv", format: outputCSV, run: func() error { return submissions(submissionsCommand, nil) }},
+		{name: "scoreboard", run: func() error { return scoreboard(scoreboardCommand, nil) }},
+		{name: "post_clar", answers: []string{"y"}, run: func() error {
+			problemId = "sum"
+			defer func() { problemId = "" }()
+			return postClarification(postClarCommand, []string{"Can the numbers be negative?"})
+		}},
+		{name: "clar", run: func() error { return fetchClars(clarCommand, nil) }},
+		{name: "clar_pending", user: "admin", run: func() error { return fetchPendingClars(clarPendingCommand, nil) }},
+	}
+
+	for _, step := range steps {
+		user := step.user
+		if user == "" {
+			user = "team1"
+		}
+		outputFormat = step.format
+		if outputFormat == "" {
+			outputFormat = outputTable
+		}
+
+		stdout, stderr := testContext(t, ts.URL, user, now, step.answers...)
+		if assert.NoError(t, step.run(), step.name) {
+			assertGolden(t, step.name, stdout.Bytes())
+		}
+		assert.Empty(t, stderr.String(), step.name)
+	}
+}
+
+// ignoreError returns nil if err has the expected message, or err otherwise.
+func ignoreError(err error, expected string) error {
+	if err != nil && err.Error() == expected {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,498 @@
+package commands
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/kirsle/configdir"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	storeKeyring   = "keyring"
+	storeFile      = "file"
+	storePlaintext = "plaintext"
+
+	// credentialStoreKey is the profile setting holding the name of the store that contains the password
+	credentialStoreKey = "credential_store"
+
+	keyringService      = "icpc-contest"
+	credentialsFileName = "credentials.json"
+
+	// passphraseEnv can be used to supply the passphrase of the encrypted file store without a prompt
+	passphraseEnv = "ICPC_PASSPHRASE"
+
+	pbkdf2Iterations = 200000
+	keyLength        = 32
+)
+
+var credentialStores = []string{storeKeyring, storeFile, storePlaintext}
+
+var errSecretNotFound = errors.New("secret not found")
+
+// storedPasswords holds the passwords read from the credential stores during this run, keyed by store and key, so
+// the passphrase of the encrypted file store is asked for at most once.
+var storedPasswords = struct {
+	sync.Mutex
+	passwords map[string]string
+}{passwords: map[string]string{}}
+
+type (
+	// credentialStore stores passwords outside of the configuration file. Secrets are identified by a key, which
+	// is derived from the profile and username.
+	credentialStore interface {
+		available() bool
+		get(key string) (string, error)
+		set(key, secret string) error
+		erase(key string) error
+	}
+
+	// keyringStore uses the keyring of the operating system, through secret-tool (Secret Service) on Linux and
+	// security (Keychain) on macOS.
+	keyringStore struct{}
+
+	// fileStore encrypts secrets with a passphrase and stores them in the configuration folder.
+	fileStore struct {
+		filename   string
+		passphrase func() (string, error)
+	}
+
+	// plaintextStore keeps the password in the active profile of the configuration file.
+	plaintextStore struct{}
+
+	// encryptedSecrets is the format of the file used by fileStore
+	encryptedSecrets struct {
+		Salt    string            `json:"salt"`
+		Check   string            `json:"check"`
+		Secrets map[string]string `json:"secrets"`
+	}
+)
+
+// credentialStoreByName returns the store with the given name.
+func credentialStoreByName(name string) (credentialStore, error) {
+	switch name {
+	case storeKeyring:
+		return keyringStore{}, nil
+	case storeFile:
+		return newFileStore(), nil
+	case storePlaintext:
+		return plaintextStore{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown credential store '%s', expected one of: %s", name, strings.Join(credentialStores, ", "))
+}
+
+// defaultCredentialStore returns the name of the store to use when none is configured: the keyring if the operating
+// system provides one, otherwise the encrypted file.
+func defaultCredentialStore() string {
+	if (keyringStore{}).available() {
+		return storeKeyring
+	}
+
+	return storeFile
+}
+
+// credentialKey identifies the password of a user of a profile in the stores. The base URL is not part of it, so the
+// password is still found after the URL of the profile changes.
+func credentialKey(profile, username string) string {
+	return fmt.Sprintf("%s@%s", username, profile)
+}
+
+// credentials returns the username and password to communicate with the API. In order of precedence, they are taken
+// from the flags, the ICPC_USERNAME and ICPC_PASSWORD environment variables, the active profile (with the password in
+// its credential store) and finally the .netrc entry of the base URL host.
+func credentials() (string, string, error) {
+	user := viper.GetString("username")
+	pass := viper.GetString("password")
+	if user != "" && pass != "" {
+		return user, pass, nil
+	}
+
+	// The credential store only holds the password of the user that logged in to the profile
+	name := viper.GetString(credentialStoreKey)
+	if user != "" && user == stringSetting(profileSettings(activeProfile), "username") && name != "" && name != storePlaintext {
+		pass, err := storedPassword(name, credentialKey(activeProfile, user))
+		if errors.Is(err, errSecretNotFound) {
+			return "", "", fmt.Errorf("no password for %s found in the %s credential store, please login again", user, name)
+		} else if err != nil {
+			return "", "", fmt.Errorf("could not retrieve password from the %s credential store; %w", name, err)
+		}
+
+		return user, pass, nil
+	}
+
+	if login, netrcPass, found := netrcCredentials(viper.GetString("baseurl")); found && (user == "" || user == login) {
+		if pass == "" {
+			pass = netrcPass
+		}
+		return login, pass, nil
+	}
+
+	return user, pass, nil
+}
+
+// storedPassword returns the password for the key from the named store, reading it only once per run.
+func storedPassword(name, key string) (string, error) {
+	storedPasswords.Lock()
+	defer storedPasswords.Unlock()
+
+	if pass, ok := storedPasswords.passwords[name+"\x00"+key]; ok {
+		return pass, nil
+	}
+
+	store, err := credentialStoreByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	pass, err := store.get(key)
+	if err != nil {
+		return "", err
+	}
+
+	storedPasswords.passwords[name+"\x00"+key] = pass
+	return pass, nil
+}
+
+// forgetStoredPassword drops the password for the key read by storedPassword, after it changed.
+func forgetStoredPassword(key string) {
+	storedPasswords.Lock()
+	defer storedPasswords.Unlock()
+
+	for _, name := range credentialStores {
+		delete(storedPasswords.passwords, name+"\x00"+key)
+	}
+}
+
+// -- keyringStore implementation
+
+func (k keyringStore) available() bool {
+	var tool string
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		tool = "secret-tool"
+	case "darwin":
+		tool = "security"
+	default:
+		return false
+	}
+
+	_, err := exec.LookPath(tool)
+	return err == nil
+}
+
+func (k keyringStore) get(key string) (string, error) {
+	if !k.available() {
+		return "", errors.New("no keyring available")
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", key, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", key)
+	}
+
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr) && keyringNotFound(exitErr):
+		return "", errSecretNotFound
+	case err != nil:
+		return "", keyringError(err)
+	case len(out) == 0:
+		return "", errSecretNotFound
+	}
+
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+func (k keyringStore) set(key, secret string) error {
+	if !k.available() {
+		return errors.New("no keyring available")
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		// Without a value for -w the password is asked for twice, so it never shows up in the process list
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keyringService, "-a", key, "-w")
+		cmd.Stdin = strings.NewReader(secret + "\n" + secret + "\n")
+	} else {
+		cmd = exec.Command("secret-tool", "store", "--label", "ICPC contest API ("+key+")", "service", keyringService, "account", key)
+		cmd.Stdin = strings.NewReader(secret)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func (k keyringStore) erase(key string) error {
+	if !k.available() {
+		return nil
+	}
+
+	if _, err := k.get(key); errors.Is(err, errSecretNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "delete-generic-password", "-s", keyringService, "-a", key)
+	} else {
+		cmd = exec.Command("secret-tool", "clear", "service", keyringService, "account", key)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// keyringNotFound returns whether the keyring tool failed because the secret does not exist: security exits with the
+// errSecItemNotFound status, secret-tool exits with 1 without an error message.
+func keyringNotFound(err *exec.ExitError) bool {
+	if runtime.GOOS == "darwin" {
+		return err.ExitCode() == 44
+	}
+
+	return err.ExitCode() == 1 && len(strings.TrimSpace(string(err.Stderr))) == 0
+}
+
+// keyringError adds the error message of the keyring tool, e.g. that the keychain is locked, to err.
+func keyringError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+
+	return err
+}
+
+// -- fileStore implementation
+
+func newFileStore() fileStore {
+	return fileStore{
+		filename:   filepath.Join(configdir.LocalConfig(configFolder), credentialsFileName),
+		passphrase: promptPassphrase,
+	}
+}
+
+// promptPassphrase reads the passphrase of the encrypted file store from the environment, or asks for it.
+func promptPassphrase() (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase := cmdCtx.prompter.Password("Passphrase for the credential store")
+	if passphrase == "" {
+		return "", fmt.Errorf("no passphrase given, set it using %s when not running interactively", passphraseEnv)
+	}
+
+	return passphrase, nil
+}
+
+func (f fileStore) available() bool {
+	return true
+}
+
+func (f fileStore) read() (encryptedSecrets, error) {
+	secrets := encryptedSecrets{Secrets: map[string]string{}}
+	bts, err := ioutil.ReadFile(f.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return secrets, nil
+	} else if err != nil {
+		return secrets, err
+	}
+
+	if err := json.Unmarshal(bts, &secrets); err != nil {
+		return secrets, fmt.Errorf("could not parse %s; %w", f.filename, err)
+	}
+	if secrets.Secrets == nil {
+		secrets.Secrets = map[string]string{}
+	}
+
+	return secrets, nil
+}
+
+func (f fileStore) write(secrets encryptedSecrets) error {
+	bts, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(f.filename, bts, 0600)
+}
+
+// key derives the encryption key from the passphrase. A check value is stored alongside the secrets, so a wrong
+// passphrase can be reported as such.
+func (f fileStore) key(secrets *encryptedSecrets) ([]byte, error) {
+	passphrase, err := f.passphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	if secrets.Salt == "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		secrets.Salt = base64.StdEncoding.EncodeToString(salt)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(secrets.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt; %w", err)
+	}
+
+	key := pbkdf2.Key([]byte(passphrase), salt, pbkdf2Iterations, keyLength, sha256.New)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(keyringService))
+	check := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if secrets.Check == "" {
+		secrets.Check = check
+	} else if !hmac.Equal([]byte(secrets.Check), []byte(check)) {
+		return nil, errors.New("wrong passphrase")
+	}
+
+	return key, nil
+}
+
+func (f fileStore) get(key string) (string, error) {
+	secrets, err := f.read()
+	if err != nil {
+		return "", err
+	}
+
+	encrypted, ok := secrets.Secrets[key]
+	if !ok {
+		return "", errSecretNotFound
+	}
+
+	k, err := f.key(&secrets)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret; %w", err)
+	}
+
+	gcm, err := newGCM(k)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid secret")
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("could not decrypt secret; %w", err)
+	}
+
+	return string(plain), nil
+}
+
+func (f fileStore) set(key, secret string) error {
+	secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	k, err := f.key(&secrets)
+	if err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(k)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	// The key is used as additional data, so secrets can not be swapped between keys
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), []byte(key))
+	secrets.Secrets[key] = base64.StdEncoding.EncodeToString(sealed)
+
+	return f.write(secrets)
+}
+
+func (f fileStore) erase(key string) error {
+	secrets, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := secrets.Secrets[key]; !ok {
+		return nil
+	}
+
+	delete(secrets.Secrets, key)
+	return f.write(secrets)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// -- plaintextStore implementation
+
+func (p plaintextStore) available() bool {
+	return true
+}
+
+func (p plaintextStore) get(key string) (string, error) {
+	pass := stringSetting(profileSettings(activeProfile), "password")
+	if pass == "" {
+		return "", errSecretNotFound
+	}
+
+	return pass, nil
+}
+
+func (p plaintextStore) set(key, secret string) error {
+	return updateProfile(map[string]interface{}{"password": secret})
+}
+
+func (p plaintextStore) erase(key string) error {
+	if _, ok := profileSettings(activeProfile)["password"]; !ok {
+		return nil
+	}
+
+	delete(profileSettings(activeProfile), "password")
+	viper.Set("password", "")
+	return writeSettings()
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	passphrase := "secret"
+	store := fileStore{
+		filename:   filepath.Join(t.TempDir(), credentialsFileName),
+		passphrase: func() (string, error) { return passphrase, nil },
+	}
+
+	_, err := store.get("team1@default")
+	assert.ErrorIs(t, err, errSecretNotFound)
+
+	assert.NoError(t, store.set("team1@default", "hunter2"))
+	assert.NoError(t, store.set("team2@default", "letmein"))
+
+	secret, err := store.get("team1@default")
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", secret)
+
+	passphrase = "wrong"
+	_, err = store.get("team2@default")
+	assert.EqualError(t, err, "wrong passphrase")
+
+	// Erasing does not need the passphrase
+	assert.NoError(t, store.erase("team1@default"))
+	_, err = store.get("team1@default")
+	assert.ErrorIs(t, err, errSecretNotFound)
+
+	passphrase = "secret"
+	secret, err = store.get("team2@default")
+	assert.NoError(t, err)
+	assert.Equal(t, "letmein", secret)
+}
+
+func TestKeyringNotFound(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("secret-tool is only used on Linux and BSD")
+	}
+
+	exitErr := func(script string) *exec.ExitError {
+		_, err := exec.Command("sh", "-c", script).Output()
+		var exitErr *exec.ExitError
+		assert.True(t, errors.As(err, &exitErr))
+		return exitErr
+	}
+
+	assert.True(t, keyringNotFound(exitErr("exit 1")))
+	assert.False(t, keyringNotFound(exitErr("echo 'Cannot autolaunch D-Bus without X11 $DISPLAY' >&2; exit 1")))
+	assert.EqualError(t, keyringError(exitErr("echo locked >&2; exit 2")), "exit status 2: locked")
+}
+
+func TestCredentialsAskPassphraseOnce(t *testing.T) {
+	dir := t.TempDir()
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, configFolder), 0755))
+	defer os.Setenv(passphraseEnv, os.Getenv(passphraseEnv))
+	os.Unsetenv(passphraseEnv)
+
+	store := newFileStore()
+	store.passphrase = func() (string, error) { return "passphrase", nil }
+	assert.NoError(t, store.set(credentialKey(activeProfile, "team1"), "hunter2"))
+	t.Cleanup(func() { forgetStoredPassword(credentialKey(activeProfile, "team1")) })
+
+	defer func(s map[string]interface{}) { settings = s }(settings)
+	settings = map[string]interface{}{profilesKey: map[string]interface{}{
+		activeProfile: map[string]interface{}{"username": "team1", credentialStoreKey: storeFile},
+	}}
+	setViper(t, "username", "team1")
+	setViper(t, "password", "")
+	setViper(t, credentialStoreKey, storeFile)
+	stdout, _ := testContext(t, "", "team1", time.Now(), "passphrase")
+
+	for i := 0; i < 3; i++ {
+		user, pass, err := credentials()
+		assert.NoError(t, err)
+		assert.Equal(t, "team1", user)
+		assert.Equal(t, "hunter2", pass)
+	}
+	assert.Equal(t, 1, strings.Count(stdout.String(), "Passphrase for the credential store"))
+}
@@ -0,0 +1,853 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// dashboardDefaultPoll is how often the dashboard polls when the event feed can not be followed
+const dashboardDefaultPoll = 30 * time.Second
+
+const (
+	enterAltScreen = "\033[?1049h\033[?25l"
+	leaveAltScreen = "\033[?25h\033[?1049l"
+	cursorHome     = "\033[H"
+)
+
+var dashboardCommand = &cobra.Command{
+	Use:   "dashboard",
+	Short: "Show the contest on a full screen dashboard",
+	Long: `Show the contest on a full screen dashboard
+
+The dashboard shows the contest clock, the problems and how your team is doing on them, the files in the current
+directory that can be submitted, your submissions and their verdicts, the clarifications (unread ones are marked with
+*) and the part of the scoreboard around your team. It is refreshed whenever the event feed reports a change, or every
+--poll interval.
+
+Keys:
+  Tab, ←, →   switch between the panes
+  ↑, ↓, j, k  move the cursor
+  s           submit the file under the cursor, for the problem detected from its name or the selected problem
+  c           post a clarification about the selected problem
+  C           post a general clarification
+  o           download and open the statement of the selected problem
+  r           refresh now
+  q, Ctrl-C   quit`,
+	Args:    cobra.NoArgs,
+	RunE:    runDashboard,
+	PreRunE: configHelper("baseurl"),
+}
+
+// The panes of the dashboard, of which all but the scoreboard can have the focus
+const (
+	paneProblems = iota
+	paneFiles
+	paneSubmissions
+	paneClarifications
+	paneScoreboard
+	paneCount
+)
+
+// The modes of the dashboard: normal, asking to confirm an action or reading a line of text
+const (
+	modeNormal = iota
+	modeConfirm
+	modeInput
+)
+
+// Keys that are not a single printable character
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyTab       = "tab"
+	keyBackTab   = "backtab"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+	keyCtrlC     = "ctrl-c"
+)
+
+// escapeSequences maps the escape sequences sent by terminals to the keys they stand for
+var escapeSequences = map[string]string{
+	"\033[A": keyUp,
+	"\033[B": keyDown,
+	"\033[C": keyRight,
+	"\033[D": keyLeft,
+	"\033OA": keyUp,
+	"\033OB": keyDown,
+	"\033OC": keyRight,
+	"\033OD": keyLeft,
+	"\033[Z": keyBackTab,
+}
+
+type (
+	// dashboardData is everything shown on the dashboard, retrieved at once.
+	dashboardData struct {
+		contest        interactor.Contest
+		state          interactor.State
+		teamId         string
+		problems       problemSet
+		languages      languageSet
+		judgementTypes judgementTypeSet
+		teams          teamSet
+		submissions    []interactor.Submission
+		judgements     judgementSet
+		clarifications []interactor.Clarification
+		scoreboard     interactor.Scoreboard
+		updated        time.Time
+	}
+
+	// dashboard is the state of the user interface.
+	dashboard struct {
+		api     interactor.ContestApi
+		raw     *rawApi // used for the event feed and downloads, nil in offline mode
+		data    dashboardData
+		files   []string
+		refresh func()
+
+		// run does slow work, such as talking to the server, without blocking the user interface. The function that
+		// work returns is called on the user interface loop to show the result.
+		run func(work func() (done func()))
+
+		focus   int
+		cursors [paneCount]int
+
+		mode      int
+		message   string
+		input     string
+		prompt    string
+		confirmed func()
+		entered   func(text string)
+
+		stateKey string
+		seen     map[string]bool
+
+		// problemFiles holds the statements of the problems once one was opened
+		problemFiles []problemFiles
+	}
+
+	// messageWriter shows everything written to it on the message line of the dashboard, instead of the terminal.
+	messageWriter chan<- string
+)
+
+func runDashboard(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return errors.New("the dashboard needs a terminal")
+	}
+
+	api, err := contestApi()
+	if err != nil {
+		return fmt.Errorf("could not connect to the server; %w", err)
+	}
+
+	data, err := loadDashboardData(api)
+	if err != nil {
+		return err
+	}
+
+	d := &dashboard{api: api}
+
+	// The credentials are resolved now, as the passphrase of the credential store can not be asked for once the
+	// terminal is in raw mode
+	if !offline {
+		raw, err := newRawApi()
+		if err != nil {
+			return err
+		}
+		d.raw = &raw
+	}
+
+	d.update(data)
+	d.stateKey = stateKey(viper.GetString("baseurl"), data.contest.Id)
+	state, err := loadContestState(d.stateKey)
+	if err != nil {
+		return err
+	}
+	d.seen = map[string]bool{}
+	for _, id := range state.SeenClarifications {
+		d.seen[id] = true
+	}
+
+	previous, err := term.MakeRaw(in)
+	if err != nil {
+		return fmt.Errorf("could not set up the terminal; %w", err)
+	}
+	defer term.Restore(in, previous)
+
+	screen := cmdCtx.stdout
+	fmt.Fprint(screen, enterAltScreen)
+	defer fmt.Fprint(screen, leaveAltScreen)
+
+	// Output of the commands the dashboard reuses, and errors of the event feed and the cache, would mess up the
+	// screen, so they are shown on the message line
+	messages := make(chan string, 8)
+	defer func(stdout, stderr io.Writer) { cmdCtx.stdout, cmdCtx.stderr = stdout, stderr }(cmdCtx.stdout, cmdCtx.stderr)
+	cmdCtx.stdout, cmdCtx.stderr = messageWriter(messages), messageWriter(messages)
+
+	// The goroutines doing background work use cmdCtx, so they must be done before the writers are restored. Only
+	// readKeys is left running, as reading stdin can not be interrupted.
+	ctx, cancel := context.WithCancel(context.Background())
+	var background sync.WaitGroup
+	defer func() {
+		cancel()
+		background.Wait()
+	}()
+
+	keys := make(chan string, 16)
+	go readKeys(os.Stdin, keys)
+
+	changed := make(chan struct{}, 1)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		watchDashboard(ctx, d.raw, data.contest.Id, changed)
+	}()
+
+	type result struct {
+		data dashboardData
+		err  error
+	}
+	results := make(chan result, 1)
+	var refreshing, refreshAgain bool
+	d.refresh = func() {
+		if refreshing {
+			refreshAgain = true
+			return
+		}
+
+		refreshing = true
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			data, err := loadDashboardData(api)
+			select {
+			case results <- result{data, err}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	finished := make(chan func(), 1)
+	d.run = func(work func() func()) {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			done := work()
+			select {
+			case finished <- done:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		width, height, err := term.GetSize(out)
+		if err != nil {
+			width, height = 80, 24
+		}
+		fmt.Fprint(screen, cursorHome+strings.Join(d.render(width, height), "\r\n"))
+
+		select {
+		case key := <-keys:
+			if d.handleKey(key) {
+				return nil
+			}
+		case <-changed:
+			d.refresh()
+		case r := <-results:
+			refreshing = false
+			if r.err != nil {
+				d.message = r.err.Error()
+			} else {
+				d.update(r.data)
+			}
+			if refreshAgain {
+				refreshAgain = false
+				d.refresh()
+			}
+		case done := <-finished:
+			done()
+		case m := <-messages:
+			d.message = m
+		case <-ticker.C:
+			d.files = submittableFiles(".", d.data.languages)
+			d.clampCursors()
+		}
+	}
+}
+
+// loadDashboardData retrieves everything shown on the dashboard. Only the submissions of the own team are kept, unless
+// the account does not belong to a team.
+func loadDashboardData(api interactor.ContestApi) (dashboardData, error) {
+	var d dashboardData
+	var err error
+	if d.contest, d.state, err = contestAndState(api); err != nil {
+		return d, err
+	}
+
+	if account, err := api.Account(); err == nil {
+		d.teamId = account.TeamId
+	}
+
+	if d.problems, err = api.Problems(); err != nil {
+		return d, fmt.Errorf("could not get problems; %w", err)
+	}
+
+	if d.languages, err = api.Languages(); err != nil {
+		return d, fmt.Errorf("could not get languages; %w", err)
+	}
+
+	if d.judgementTypes, err = api.JudgementTypes(); err != nil {
+		return d, fmt.Errorf("could not get judgement types; %w", err)
+	}
+
+	if d.teams, err = api.Teams(); err != nil {
+		return d, fmt.Errorf("could not retrieve teams; %w", err)
+	}
+
+	submissions, err := api.Submissions()
+	if err != nil {
+		return d, fmt.Errorf("could not get submissions; %w", err)
+	}
+	for _, s := range submissions {
+		if d.teamId == "" || strings.EqualFold(s.TeamId, d.teamId) {
+			d.submissions = append(d.submissions, s)
+		}
+	}
+	sort.SliceStable(d.submissions, func(i, j int) bool {
+		return d.submissions[i].ContestTime > d.submissions[j].ContestTime
+	})
+
+	if d.judgements, err = api.Judgements(); err != nil {
+		return d, fmt.Errorf("could not get judgements; %w", err)
+	}
+
+	if d.clarifications, err = api.Clarifications(); err != nil {
+		return d, fmt.Errorf("could not retrieve clarifications; %w", err)
+	}
+
+	// Some servers have no scoreboard before the contest starts
+	if sc, err := api.Scoreboard(); err == nil {
+		d.scoreboard = sc
+	}
+
+	d.updated = cmdCtx.now()
+	return d, nil
+}
+
+// watchDashboard signals changed whenever the event feed of the contest has news, or every --poll interval, until the
+// context is cancelled. Nothing changes in offline mode, when there is no raw api.
+func watchDashboard(ctx context.Context, raw *rawApi, contestId string, changed chan<- struct{}) {
+	if raw == nil {
+		return
+	}
+
+	signal := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	interval := dashboardPoll
+	if interval == 0 {
+		feed := &eventFeed{
+			api:       *raw,
+			contestId: contestId,
+			types:     []string{"contests", "state", "problems", "submissions", "judgements", "clarifications"},
+		}
+		err := feed.follow(ctx, func(event, []byte) error {
+			signal()
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			fmt.Fprintf(cmdCtx.stderr, "could not follow the event feed (%v), polling every %v instead\n", err, dashboardDefaultPoll)
+		}
+		interval = dashboardDefaultPoll
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			signal()
+		}
+	}
+}
+
+func (w messageWriter) Write(p []byte) (int, error) {
+	select {
+	case w <- strings.TrimSpace(string(p)):
+	default:
+	}
+
+	return len(p), nil
+}
+
+// readKeys sends the keys read from r until it fails.
+func readKeys(r io.Reader, keys chan<- string) {
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for _, key := range parseKeys(buf[:n]) {
+			keys <- key
+		}
+	}
+}
+
+// parseKeys splits the input of a terminal in raw mode into keys. Escape sequences that are not known are skipped.
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		if b[0] == '\033' && len(b) > 2 && (b[1] == '[' || b[1] == 'O') {
+			if key, ok := escapeSequences[string(b[:3])]; ok {
+				keys = append(keys, key)
+				b = b[3:]
+				continue
+			}
+
+			// Skip the parameters up to the final byte of the sequence
+			end := 2
+			for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+				end++
+			}
+			b = b[min(end+1, len(b)):]
+			continue
+		}
+
+		switch b[0] {
+		case '\033':
+			keys = append(keys, keyEscape)
+		case '\r', '\n':
+			keys = append(keys, keyEnter)
+		case '\t':
+			keys = append(keys, keyTab)
+		case 0x7f, '\b':
+			keys = append(keys, keyBackspace)
+		case 0x03:
+			keys = append(keys, keyCtrlC)
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r >= ' ' && r != utf8.RuneError {
+				keys = append(keys, string(r))
+			}
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+
+	return keys
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// update replaces the data shown, keeping the cursors within the panes.
+func (d *dashboard) update(data dashboardData) {
+	d.data = data
+	d.files = submittableFiles(".", data.languages)
+	d.clampCursors()
+}
+
+// paneLength returns the number of lines the cursor can move over in a pane.
+func (d *dashboard) paneLength(pane int) int {
+	switch pane {
+	case paneProblems:
+		return len(d.data.problems)
+	case paneFiles:
+		return len(d.files)
+	case paneSubmissions:
+		return len(d.data.submissions)
+	case paneClarifications:
+		return len(d.data.clarifications)
+	}
+
+	return 0
+}
+
+func (d *dashboard) clampCursors() {
+	for pane := range d.cursors {
+		if d.cursors[pane] >= d.paneLength(pane) {
+			d.cursors[pane] = d.paneLength(pane) - 1
+		}
+		if d.cursors[pane] < 0 {
+			d.cursors[pane] = 0
+		}
+	}
+}
+
+// handleKey acts on a key, returning whether the dashboard should quit.
+func (d *dashboard) handleKey(key string) bool {
+	switch d.mode {
+	case modeConfirm:
+		action := d.confirmed
+		d.mode, d.confirmed, d.message = modeNormal, nil, ""
+		if key == "y" || key == "Y" {
+			action()
+		} else {
+			d.message = "Cancelled"
+		}
+		return false
+	case modeInput:
+		switch key {
+		case keyEnter:
+			d.mode, d.message = modeNormal, ""
+			if text := strings.TrimSpace(d.input); text != "" {
+				d.entered(text)
+			} else {
+				d.message = "Cancelled, no text given"
+			}
+		case keyEscape, keyCtrlC:
+			d.mode, d.message = modeNormal, "Cancelled"
+		case keyBackspace:
+			if _, size := utf8.DecodeLastRuneInString(d.input); size > 0 {
+				d.input = d.input[:len(d.input)-size]
+			}
+		default:
+			if utf8.RuneCountInString(key) == 1 {
+				d.input += key
+			}
+		}
+		return false
+	}
+
+	switch key {
+	case "q", keyCtrlC:
+		return true
+	case keyTab, keyRight:
+		d.focus = (d.focus + 1) % paneScoreboard
+	case keyBackTab, keyLeft:
+		d.focus = (d.focus + paneScoreboard - 1) % paneScoreboard
+	case keyUp, "k":
+		d.cursors[d.focus]--
+	case keyDown, "j":
+		d.cursors[d.focus]++
+	case "s":
+		d.submitSelectedFile()
+	case "c":
+		problem, ok := d.selectedProblem()
+		if !ok {
+			d.message = "No problem selected"
+			break
+		}
+		d.askClarification(problem)
+	case "C":
+		d.askClarification(interactor.Problem{})
+	case "o":
+		d.openStatement()
+	case "r":
+		d.message = "Refreshing..."
+		d.refresh()
+	}
+
+	d.clampCursors()
+	if d.focus == paneClarifications {
+		d.markSelectedSeen()
+	}
+
+	return false
+}
+
+// ask asks to confirm the action on the message line.
+func (d *dashboard) ask(question string, action func()) {
+	d.mode, d.message, d.confirmed = modeConfirm, question+" [y/N]", action
+}
+
+// read reads a line of text on the message line, and passes it to entered.
+func (d *dashboard) read(prompt string, entered func(text string)) {
+	d.mode, d.prompt, d.input, d.entered = modeInput, prompt, "", entered
+}
+
+func (d *dashboard) selectedProblem() (interactor.Problem, bool) {
+	if len(d.data.problems) == 0 {
+		return interactor.Problem{}, false
+	}
+
+	return d.data.problems[d.cursors[paneProblems]], true
+}
+
+// submitSelectedFile submits the file under the cursor of the files pane after confirmation. The problem is detected
+// from the name of the file or its directory, or else the problem under the cursor of the problems pane is used.
+func (d *dashboard) submitSelectedFile() {
+	if len(d.files) == 0 {
+		d.message = "No file to submit in the current directory"
+		return
+	}
+
+	path := d.files[d.cursors[paneFiles]]
+	problem, language, err := detectProblemAndLanguage([]string{path}, d.data.problems, d.data.languages)
+	if err != nil && language.Id != "" {
+		problem, _ = d.selectedProblem()
+		err = nil
+	}
+	if err != nil || problem.Id == "" {
+		d.message = fmt.Sprintf("Could not submit %s; no known problem or language detected", path)
+		return
+	}
+
+	var entry string
+	if language.EntryPointRequired {
+		if entry = detectEntryPoint(language, []string{path}); entry == "" {
+			d.message = fmt.Sprintf("Could not submit %s; entry point required but not detected", path)
+			return
+		}
+	}
+
+	d.ask(fmt.Sprintf("Submit %s for problem %s: %s in %s?", path, problem.Label, problem.Name, language.Name), func() {
+		d.message = fmt.Sprintf("Submitting %s...", path)
+		d.run(func() func() {
+			collected, err := collectSubmissionFiles([]string{path})
+			if err != nil {
+				return d.show(err.Error())
+			}
+
+			files, err := addSubmissionFiles(collected)
+			if err != nil {
+				return d.show(err.Error())
+			}
+
+			submission, err := d.api.PostSubmission(problem.Id, language.Id, entry, files)
+			if err != nil {
+				return d.show(fmt.Sprintf("Could not submit: %v", err))
+			}
+
+			return func() {
+				d.message = fmt.Sprintf("Submission %s for problem %s accepted at %v", submission.Id, problem.Label, submission.ContestTime)
+				d.refresh()
+			}
+		})
+	})
+}
+
+// show returns a function that shows the message, for the result of work passed to run.
+func (d *dashboard) show(message string) func() {
+	return func() {
+		d.message = message
+	}
+}
+
+// askClarification reads the text of a clarification about the problem, or a general one, and posts it.
+func (d *dashboard) askClarification(problem interactor.Problem) {
+	prompt := "Clarification (general): "
+	if problem.Id != "" {
+		prompt = fmt.Sprintf("Clarification about %s: %s: ", problem.Label, problem.Name)
+	}
+
+	d.read(prompt, func(text string) {
+		d.message = "Posting clarification..."
+		d.run(func() func() {
+			clar, err := d.api.PostClarification(problem.Id, text)
+			if err != nil {
+				return d.show(fmt.Sprintf("Could not post clarification: %v", err))
+			}
+
+			return func() {
+				d.message = fmt.Sprintf("Clarification accepted at %v", clar.ContestTime)
+				d.refresh()
+			}
+		})
+	})
+}
+
+// openStatement downloads the statement of the problem under the cursor to <label>/statement.<ext>, like problem
+// download does, and opens it with the default application.
+func (d *dashboard) openStatement() {
+	problem, ok := d.selectedProblem()
+	if !ok {
+		d.message = "No problem selected"
+		return
+	}
+
+	dir, err := problemDir(".", problem)
+	if err != nil {
+		d.message = err.Error()
+		return
+	}
+
+	if d.raw == nil {
+		d.message = fmt.Sprintf("Statements are %v", errOffline)
+		return
+	}
+	raw := *d.raw
+
+	d.message = fmt.Sprintf("Downloading the statement of problem %s...", problem.Label)
+	contestId, problemFiles := d.data.contest.Id, d.problemFiles
+	d.run(func() func() {
+		if problemFiles == nil {
+			if err := raw.getJSON(context.Background(), "contests/"+url.PathEscape(contestId)+"/problems", &problemFiles); err != nil {
+				return d.show(fmt.Sprintf("Could not get problem files; %v", err))
+			}
+		}
+
+		message := downloadStatement(raw, problemFiles, problem, dir)
+		return func() {
+			d.problemFiles = problemFiles
+			d.message = message
+		}
+	})
+}
+
+// downloadStatement downloads the statement of the problem to dir and opens it, returning the message to show.
+func downloadStatement(raw rawApi, problemFiles []problemFiles, problem interactor.Problem, dir string) string {
+	var statement fileReference
+	for _, f := range problemFiles {
+		if f.Id == problem.Id && len(f.Statement) > 0 {
+			statement = f.Statement[0]
+		}
+	}
+	if statement.Href == "" {
+		return fmt.Sprintf("No statement available for problem %s", problem.Label)
+	}
+
+	filename := filepath.Join(dir, "statement"+statementExtension(statement))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err.Error()
+	}
+
+	if _, err := downloadFile(raw, statement.Href, filename); err != nil {
+		return fmt.Sprintf("Could not download statement; %v", err)
+	}
+
+	if err := openFile(filename); err != nil {
+		return fmt.Sprintf("Statement saved to %s, but could not open it; %v", filename, err)
+	}
+
+	return fmt.Sprintf("Opened %s", filename)
+}
+
+// markSelectedSeen marks the clarification under the cursor as seen, like listing it with clar does.
+func (d *dashboard) markSelectedSeen() {
+	clars := threadClarifications(d.data.clarifications)
+	if len(clars) == 0 {
+		return
+	}
+
+	id := clars[d.cursors[paneClarifications]].Id
+	if d.seen[id] {
+		return
+	}
+	d.seen[id] = true
+
+	state, err := loadContestState(d.stateKey)
+	if err == nil {
+		state.SeenClarifications = append(state.SeenClarifications, id)
+		err = saveContestState(d.stateKey, state)
+	}
+	if err != nil {
+		d.message = err.Error()
+	}
+}
+
+// submittableFiles returns the files in dir and its direct subdirectories that have the extension of a language,
+// most recently modified first. Hidden files and the files left out of directory submissions are skipped.
+func submittableFiles(dir string, languages languageSet) []string {
+	type file struct {
+		path    string
+		modTime time.Time
+	}
+
+	var files []file
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == dir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+
+		name := filepath.ToSlash(rel)
+		if strings.HasPrefix(info.Name(), ".") || excluded(name, info.IsDir(), defaultExcludes) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			if strings.Count(name, "/") > 0 {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+		if _, ok := languages.byExtension(extension); ok {
+			files = append(files, file{rel, info.ModTime()})
+		}
+		return nil
+	})
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.path)
+	}
+
+	return paths
+}
+
+// openFile opens the file with the default application, without waiting for it.
+func openFile(filename string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", filename)
+	case "darwin":
+		cmd = exec.Command("open", filename)
+	default:
+		cmd = exec.Command("xdg-open", filename)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"q", []string{"q"}},
+		{"\033[A\033[B\033OC\033[D", []string{keyUp, keyDown, keyRight, keyLeft}},
+		{"\t\033[Z", []string{keyTab, keyBackTab}},
+		{"hé\r", []string{"h", "é", keyEnter}},
+		{"\033", []string{keyEscape}},
+		{"\x7f\b\x03", []string{keyBackspace, keyBackspace, keyCtrlC}},
+		{"\033[5~x", []string{"x"}},
+		{"\x01", nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, parseKeys([]byte(tt.input)), "%q", tt.input)
+	}
+}
+
+func TestProblemStatuses(t *testing.T) {
+	submissions := []interactor.Submission{
+		{Id: "4", ProblemId: "sum"},
+		{Id: "3", ProblemId: "hello"},
+		{Id: "2", ProblemId: "hello"},
+		{Id: "1", ProblemId: "hello"},
+	}
+	judgements := judgementSet{
+		{Id: "j1", SubmissionId: "1", JudgementTypeId: "WA"},
+		{Id: "j2", SubmissionId: "2", JudgementTypeId: "AC"},
+		{Id: "j3", SubmissionId: "3", JudgementTypeId: "TLE"},
+		{Id: "j4", SubmissionId: "4"},
+	}
+	judgementTypes := judgementTypeSet{{Id: "AC", Solved: true}, {Id: "WA"}, {Id: "TLE"}}
+
+	statuses := problemStatuses(submissions, judgements, judgementTypes)
+	assert.Equal(t, map[string]problemStatus{
+		"hello": {solved: true, attempts: 3, latest: "TLE"},
+		"sum":   {pending: 1},
+	}, statuses)
+
+	tests := []struct {
+		status problemStatus
+		text   string
+		color  string
+	}{
+		{problemStatus{}, "", ""},
+		{statuses["hello"], "solved, 3 tries", colorGreen},
+		{statuses["sum"], "1 pending", colorYellow},
+		{problemStatus{attempts: 1, pending: 1, latest: "WA"}, "WA, 1 try, 1 pending", colorYellow},
+		{problemStatus{attempts: 2, latest: "WA"}, "WA, 2 tries", colorRed},
+	}
+	for _, tt := range tests {
+		text, color := tt.status.describe()
+		assert.Equal(t, tt.text, text)
+		assert.Equal(t, tt.color, color)
+	}
+}
+
+func TestDashboardRender(t *testing.T) {
+	defer func(c commandContext, l *time.Location) { cmdCtx, time.Local = c, l }(cmdCtx, time.Local)
+	time.Local = time.UTC
+	start := time.Date(2021, 4, 1, 9, 0, 0, 0, time.UTC)
+	cmdCtx.now = func() time.Time { return start.Add(90 * time.Minute) }
+
+	startTime := interactor.ApiTime(start)
+	var teams teamSet
+	var rows []interactor.Row
+	for i := 1; i <= 8; i++ {
+		id := string(rune('0' + i))
+		teams = append(teams, interactor.Team{Id: id, Name: "Team " + id})
+		rows = append(rows, interactor.Row{Rank: i, TeamId: interactor.Identifier(id), Score: interactor.Score{NumSolved: 9 - i, TotalTime: 100 * (9 - i)}})
+	}
+
+	d := &dashboard{
+		data: dashboardData{
+			contest: interactor.Contest{
+				Name:                     "Practice",
+				StartTime:                startTime,
+				Duration:                 interactor.ApiRelTime(5 * time.Hour),
+				ScoreboardFreezeDuration: interactor.ApiRelTime(time.Hour),
+			},
+			teamId:         "6",
+			problems:       problemSet{{Id: "hello", Label: "A", Name: "Hello World"}, {Id: "sum", Label: "B", Name: "Sum"}, {Id: "bits", Label: "C", Name: "Bits"}},
+			languages:      languageSet{{Id: "python3", Name: "Python 3", Extensions: []string{"py"}}},
+			judgementTypes: judgementTypeSet{{Id: "AC", Name: "Accepted", Solved: true}, {Id: "WA", Name: "Wrong Answer"}},
+			teams:          teams,
+			submissions: []interactor.Submission{
+				{Id: "3", ProblemId: "sum", LanguageId: "python3", ContestTime: interactor.ApiRelTime(80 * time.Minute)},
+				{Id: "2", ProblemId: "hello", LanguageId: "python3", ContestTime: interactor.ApiRelTime(30 * time.Minute)},
+				{Id: "1", ProblemId: "hello", LanguageId: "python3", ContestTime: interactor.ApiRelTime(20 * time.Minute)},
+			},
+			judgements: judgementSet{
+				{Id: "j1", SubmissionId: "1", JudgementTypeId: "WA"},
+				{Id: "j2", SubmissionId: "2", JudgementTypeId: "AC"},
+			},
+			clarifications: []interactor.Clarification{
+				{Id: "b1", Text: "Welcome!", ContestTime: interactor.ApiRelTime(time.Minute)},
+				{Id: "q1", FromTeamId: "6", ProblemId: "sum", Text: "Can n be 0?\nThanks", ContestTime: interactor.ApiRelTime(40 * time.Minute)},
+				{Id: "a1", ToTeamId: "6", ReplyToId: "q1", ProblemId: "sum", Text: "No", ContestTime: interactor.ApiRelTime(45 * time.Minute)},
+			},
+			scoreboard: interactor.Scoreboard{Rows: rows},
+			updated:    start.Add(90 * time.Minute),
+		},
+		files:   []string{"sum.py", "hello/main.py"},
+		seen:    map[string]bool{"b1": true, "q1": true},
+		focus:   paneSubmissions,
+		message: "Submission 3 for problem B accepted at 1h20m",
+	}
+	d.cursors[paneProblems] = 1
+
+	assertGolden(t, "dashboard", []byte(strings.Join(d.render(100, 30), "\n")+"\n"))
+
+	// Terminals that are too small only get a hint
+	lines := d.render(40, 10)
+	assert.Len(t, lines, 10)
+	assert.Equal(t, "Make the terminal larger to show the da…", lines[0])
+}
+
+type clarificationApi struct {
+	interactor.ContestApi
+	posted []interactor.Clarification
+}
+
+func (a *clarificationApi) PostClarification(problemId, text string) (interactor.Clarification, error) {
+	clar := interactor.Clarification{Id: "c1", ProblemId: problemId, Text: text}
+	a.posted = append(a.posted, clar)
+	return clar, nil
+}
+
+func TestDashboardKeys(t *testing.T) {
+	api := &clarificationApi{}
+	refreshed := 0
+	d := &dashboard{
+		api:     api,
+		refresh: func() { refreshed++ },
+		data: dashboardData{
+			problems: problemSet{{Id: "hello", Label: "A", Name: "Hello World"}, {Id: "sum", Label: "B", Name: "Sum"}},
+		},
+	}
+	d.run = func(work func() func()) { work()() }
+
+	press := func(keys ...string) {
+		for _, key := range keys {
+			assert.False(t, d.handleKey(key))
+		}
+	}
+
+	// The cursor stays within the pane
+	press(keyDown, "j", "j")
+	assert.Equal(t, 1, d.cursors[paneProblems])
+	press(keyUp, keyUp)
+	assert.Equal(t, 0, d.cursors[paneProblems])
+
+	press(keyTab, keyTab, keyBackTab)
+	assert.Equal(t, paneFiles, d.focus)
+	press(keyLeft, keyDown)
+
+	// A clarification about the selected problem, with a typo corrected
+	press("c", "H", "i", "x", keyBackspace, "?", keyEnter)
+	assert.Equal(t, []interactor.Clarification{{Id: "c1", ProblemId: "sum", Text: "Hi?"}}, api.posted)
+	assert.Equal(t, modeNormal, d.mode)
+	assert.Equal(t, 1, refreshed)
+
+	// Typing q or j while entering text does not quit or move
+	press("C", "q", "j", keyEscape)
+	assert.Len(t, api.posted, 1)
+	assert.Equal(t, "Cancelled", d.message)
+
+	press("C", "j", "q", keyEnter)
+	assert.Equal(t, interactor.Clarification{Id: "c1", Text: "jq"}, api.posted[1])
+
+	// Without files nothing can be submitted
+	press("s")
+	assert.Equal(t, modeNormal, d.mode)
+	assert.Equal(t, "No file to submit in the current directory", d.message)
+
+	assert.True(t, d.handleKey("q"))
+	assert.True(t, d.handleKey(keyCtrlC))
+}
+
+func TestSubmittableFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"a.py", "hello/main.py", "hello/deep/b.py", "notes.txt", ".hidden.py", ".git/c.py", "sum/sum.py"} {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, os.WriteFile(filename, nil, 0644))
+		assert.NoError(t, os.Chtimes(filename, now, now.Add(time.Duration(i)*time.Minute)))
+	}
+
+	languages := languageSet{{Id: "python3", Extensions: []string{"py"}}}
+	assert.Equal(t, []string{filepath.Join("sum", "sum.py"), filepath.Join("hello", "main.py"), "a.py"}, submittableFiles(dir, languages))
+}
@@ -0,0 +1,442 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	interactor "github.com/icpctools/api-interactor"
+)
+
+// Attributes that only undo themselves, so that colored text can be shown on a selected line
+const (
+	styleBold      = "\033[1m"
+	styleDim       = "\033[2m"
+	styleNormal    = "\033[22m"
+	styleReverse   = "\033[7m"
+	styleNoReverse = "\033[27m"
+	colorDefault   = "\033[39m"
+)
+
+type (
+	// paneLine is a line in a pane: text on the left, and optionally colored text on the right.
+	paneLine struct {
+		text  string
+		right string
+		color string
+		bold  bool
+	}
+
+	// problemStatus summarizes the submissions of a team for a problem.
+	problemStatus struct {
+		solved   bool
+		attempts int
+		pending  int
+		latest   string
+	}
+)
+
+// problemStatuses returns the status of each problem for the given submissions of a team, newest first, by problem id.
+func problemStatuses(submissions []interactor.Submission, judgements judgementSet, judgementTypes judgementTypeSet) map[string]problemStatus {
+	statuses := map[string]problemStatus{}
+	for _, s := range submissions {
+		st := statuses[s.ProblemId]
+		js, judged := judgements.bySubmissionId(s.Id)
+		if !judged || latestJudgement(js).JudgementTypeId == "" {
+			st.pending++
+			statuses[s.ProblemId] = st
+			continue
+		}
+
+		judgementType, _ := judgementTypes.byId(latestJudgement(js).JudgementTypeId)
+		st.attempts++
+		st.solved = st.solved || judgementType.Solved
+		if st.latest == "" {
+			st.latest = latestJudgement(js).JudgementTypeId
+		}
+		statuses[s.ProblemId] = st
+	}
+
+	return statuses
+}
+
+// describe returns the text and color to show for the status, e.g. "solved, 2 tries" or "WA, 1 try, 1 pending".
+func (st problemStatus) describe() (string, string) {
+	var parts []string
+	var color string
+	switch {
+	case st.solved:
+		parts, color = append(parts, "solved"), colorGreen
+	case st.attempts > 0:
+		parts, color = append(parts, st.latest), colorRed
+	}
+
+	if st.attempts == 1 {
+		parts = append(parts, "1 try")
+	} else if st.attempts > 1 {
+		parts = append(parts, fmt.Sprintf("%d tries", st.attempts))
+	}
+
+	if st.pending > 0 {
+		parts = append(parts, fmt.Sprintf("%d pending", st.pending))
+		if !st.solved {
+			color = colorYellow
+		}
+	}
+
+	return strings.Join(parts, ", "), color
+}
+
+// render returns the lines of the dashboard for a terminal of the given size.
+func (d *dashboard) render(width, height int) []string {
+	var lines []string
+	if width < 60 || height < 12 {
+		lines = append(lines, fit("Make the terminal larger to show the dashboard, or press q to quit", width))
+		for len(lines) < height {
+			lines = append(lines, fit("", width))
+		}
+		return lines
+	}
+
+	body := height - 3
+	leftWidth := width * 2 / 5
+	rightWidth := width - leftWidth - 1
+
+	problemsHeight := len(d.data.problems) + 2
+	if limit := body * 3 / 5; problemsHeight > limit {
+		problemsHeight = limit
+	}
+	if problemsHeight < 3 {
+		problemsHeight = 3
+	}
+	filesHeight := body - problemsHeight
+
+	scoreboardHeight := 7
+	if body/3 < scoreboardHeight {
+		scoreboardHeight = body / 3
+	}
+	submissionsHeight := (body - scoreboardHeight) / 2
+	clarificationsHeight := body - scoreboardHeight - submissionsHeight
+
+	left := append(
+		d.box(paneProblems, "Problems", leftWidth, problemsHeight, d.problemLines(), "No problems available yet"),
+		d.box(paneFiles, "Files", leftWidth, filesHeight, d.fileLines(), "No files to submit in the current directory")...)
+	right := append(append(
+		d.box(paneSubmissions, "Submissions", rightWidth, submissionsHeight, d.submissionLines(), "No submissions yet"),
+		d.box(paneClarifications, "Clarifications", rightWidth, clarificationsHeight, d.clarificationLines(), "No clarifications yet")...),
+		d.box(paneScoreboard, "Scoreboard", rightWidth, scoreboardHeight, d.scoreboardLines(scoreboardHeight-2), "No scoreboard available")...)
+
+	lines = append(lines, d.headerLine(width))
+	for i := 0; i < body; i++ {
+		lines = append(lines, left[i]+" "+right[i])
+	}
+
+	return append(lines, d.messageLine(width), d.helpLine(width))
+}
+
+// fit truncates or pads s to exactly width characters. s must not contain escape sequences.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	runes := []rune(s)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+// box draws a pane with a border, scrolled so that its cursor is visible.
+func (d *dashboard) box(pane int, title string, width, height int, content []paneLine, empty string) []string {
+	title = " " + title + " "
+	top := "┌─" + title + strings.Repeat("─", max(width-3-len([]rune(title)), 0)) + "┐"
+	if pane == d.focus {
+		top = "┌─" + styleBold + title + styleNormal + strings.Repeat("─", max(width-3-len([]rune(title)), 0)) + "┐"
+	}
+	lines := []string{top}
+
+	rows := height - 2
+	cursor := -1
+	if pane != paneScoreboard && len(content) > 0 {
+		cursor = d.cursors[pane]
+	}
+
+	offset := 0
+	if cursor >= rows {
+		offset = cursor - rows + 1
+	}
+
+	for i := 0; i < rows; i++ {
+		var line string
+		switch {
+		case offset+i < len(content):
+			line = formatPaneLine(content[offset+i], width-2, offset+i == cursor, pane == d.focus)
+		case i == 0 && len(content) == 0:
+			line = styleDim + fit(" "+empty, width-2) + styleNormal
+		default:
+			line = fit("", width-2)
+		}
+		lines = append(lines, "│"+line+"│")
+	}
+
+	return append(lines, "└"+strings.Repeat("─", width-2)+"┘")
+}
+
+// formatPaneLine formats a line of a pane of the given width. The cursor is marked, and shown in reverse when the pane
+// has the focus.
+func formatPaneLine(l paneLine, width int, selected, focused bool) string {
+	marker := " "
+	if selected {
+		marker = "›"
+	}
+
+	// The marker and a space on the right
+	inner := width - 2
+	rightWidth := len([]rune(l.right))
+	if rightWidth > inner/2 {
+		rightWidth = inner / 2
+	}
+
+	text := fit(l.text, inner)
+	if rightWidth > 0 {
+		right := fit(l.right, rightWidth)
+		if l.color != "" {
+			right = l.color + right + colorDefault
+		}
+		text = fit(l.text, inner-rightWidth-1) + " " + right
+	}
+
+	line := marker + text + " "
+	if l.bold {
+		line = styleBold + line + styleNormal
+	}
+	if selected && focused {
+		line = styleReverse + line + styleNoReverse
+	}
+
+	return line
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// headerLine shows the contest clock on the left, and the team and its rank on the right.
+func (d *dashboard) headerLine(width int) string {
+	row := statusTable(d.data.contest, d.data.state, cmdCtx.now()).Rows[0]
+	name, phase, start, elapsed, remaining, freeze := row[0], row[1], row[2], row[3], row[4], row[5]
+
+	clock := fmt.Sprintf("%s  %s", name, phase)
+	if elapsed != "" {
+		clock += fmt.Sprintf("  elapsed %s  remaining %s", elapsed, remaining)
+	} else {
+		clock += "  start " + start
+	}
+	if freeze != "" {
+		clock += "  freeze " + freeze
+	}
+
+	team := "jury"
+	if d.data.teamId != "" {
+		team = d.teamName(d.data.teamId)
+		for _, r := range d.data.scoreboard.Rows {
+			if string(r.TeamId) == d.data.teamId {
+				team += fmt.Sprintf(", rank %d", r.Rank)
+			}
+		}
+	}
+
+	return styleBold + alignRight(clock, team, width) + styleNormal
+}
+
+// alignRight returns left and right on a line of exactly width characters, truncating right first.
+func alignRight(left, right string, width int) string {
+	rightWidth := width - len([]rune(left)) - 2
+	switch {
+	case rightWidth >= len([]rune(right)):
+		return left + strings.Repeat(" ", width-len([]rune(left))-len([]rune(right))) + right
+	case rightWidth < 8:
+		return fit(left, width)
+	}
+
+	return left + "  " + fit(right, rightWidth)
+}
+
+// messageLine shows the last message, the question to confirm or the text being entered.
+func (d *dashboard) messageLine(width int) string {
+	switch d.mode {
+	case modeConfirm:
+		return colorYellow + fit(d.message, width) + colorDefault
+	case modeInput:
+		// Show the end of long texts, where the user is typing
+		line := []rune(d.prompt + d.input + "_")
+		if len(line) > width {
+			line = line[len(line)-width:]
+		}
+		return fit(string(line), width)
+	}
+
+	return fit(d.message, width)
+}
+
+func (d *dashboard) helpLine(width int) string {
+	help := "Tab pane  ↑↓ move  s submit  c/C clarification  o statement  r refresh  q quit"
+	switch d.mode {
+	case modeConfirm:
+		help = "y confirm  any other key cancel"
+	case modeInput:
+		help = "Enter send  Esc cancel"
+	}
+
+	return styleDim + alignRight(help, "updated "+d.data.updated.Local().Format("15:04:05"), width) + styleNormal
+}
+
+func (d *dashboard) teamName(id string) string {
+	team, ok := d.data.teams.byId(id)
+	switch {
+	case !ok:
+		return id
+	case team.DisplayName != "":
+		return team.DisplayName
+	default:
+		return team.Name
+	}
+}
+
+func (d *dashboard) problemLabel(id string) string {
+	if problem, ok := d.data.problems.byId(id); ok {
+		return problem.Label
+	}
+
+	return id
+}
+
+func (d *dashboard) problemLines() []paneLine {
+	statuses := problemStatuses(d.data.submissions, d.data.judgements, d.data.judgementTypes)
+
+	var lines []paneLine
+	for _, p := range d.data.problems {
+		status, color := statuses[p.Id].describe()
+		lines = append(lines, paneLine{text: fmt.Sprintf("%-2s %s", p.Label, p.Name), right: status, color: color})
+	}
+
+	return lines
+}
+
+// fileLines shows the files that can be submitted, with the problem and language that would be used.
+func (d *dashboard) fileLines() []paneLine {
+	var lines []paneLine
+	for _, path := range d.files {
+		problem, language, _ := detectProblemAndLanguage([]string{path}, d.data.problems, d.data.languages)
+		var detected []string
+		if problem.Id != "" {
+			detected = append(detected, problem.Label)
+		}
+		if language.Id != "" {
+			detected = append(detected, language.Name)
+		}
+		lines = append(lines, paneLine{text: path, right: strings.Join(detected, ", ")})
+	}
+
+	return lines
+}
+
+func (d *dashboard) submissionLines() []paneLine {
+	var lines []paneLine
+	for _, s := range d.data.submissions {
+		language := s.LanguageId
+		if l, ok := d.data.languages.byId(s.LanguageId); ok {
+			language = l.Name
+		}
+
+		line := paneLine{text: fmt.Sprintf("%-3s %-2s %-9v %s", s.Id, d.problemLabel(s.ProblemId), s.ContestTime, language)}
+		js, judged := d.data.judgements.bySubmissionId(s.Id)
+		switch {
+		case !judged:
+			line.right, line.color = "queued", colorYellow
+		case latestJudgement(js).JudgementTypeId == "":
+			line.right, line.color = "judging", colorYellow
+		default:
+			judgement := latestJudgement(js)
+			line.right, line.color = judgement.JudgementTypeId, colorRed
+			if jt, ok := d.data.judgementTypes.byId(judgement.JudgementTypeId); ok && jt.Solved {
+				line.color = colorGreen
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// clarificationLines shows the first line of each clarification, threaded like clar does. Unread clarifications are
+// marked with * and shown in bold.
+func (d *dashboard) clarificationLines() []paneLine {
+	var lines []paneLine
+	for _, c := range threadClarifications(d.data.clarifications) {
+		marker := " "
+		if !d.seen[c.Id] {
+			marker = "*"
+		}
+
+		var about string
+		if c.depth > 0 {
+			about = strings.Repeat("  ", c.depth-1) + "↳ "
+		}
+		if c.ProblemId != "" {
+			about += d.problemLabel(c.ProblemId) + ": "
+		}
+
+		var kind string
+		switch {
+		case c.FromTeamId == "" && c.ToTeamId == "":
+			kind = "broadcast"
+		case c.FromTeamId != "":
+			kind = "sent"
+		default:
+			kind = "response"
+		}
+
+		text := strings.TrimSpace(strings.SplitN(c.Text, "\n", 2)[0])
+		lines = append(lines, paneLine{
+			text:  fmt.Sprintf("%s %-9v %s%s", marker, c.ContestTime, about, text),
+			right: kind,
+			bold:  !d.seen[c.Id],
+		})
+	}
+
+	return lines
+}
+
+// scoreboardLines shows rows of the scoreboard around the rank of the team, or the top rows for other accounts.
+func (d *dashboard) scoreboardLines(rows int) []paneLine {
+	all := d.data.scoreboard.Rows
+	start := 0
+	for i, r := range all {
+		if d.data.teamId != "" && string(r.TeamId) == d.data.teamId {
+			start = i - rows/2
+		}
+	}
+	if start > len(all)-rows {
+		start = len(all) - rows
+	}
+	if start < 0 {
+		start = 0
+	}
+
+	var lines []paneLine
+	for i := start; i < len(all) && i < start+rows; i++ {
+		r := all[i]
+		lines = append(lines, paneLine{
+			text:  fmt.Sprintf("%3d  %s", r.Rank, d.teamName(string(r.TeamId))),
+			right: fmt.Sprintf("%2d %5d", r.Score.NumSolved, r.Score.TotalTime),
+			bold:  string(r.TeamId) == d.data.teamId,
+		})
+	}
+
+	return lines
+}
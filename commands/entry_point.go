@@ -0,0 +1,239 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/spf13/viper"
+)
+
+// entryPointRule describes how to detect the entry point of a language. The main file is the first file whose contents
+// match Main, or the first file if none does. The entry point is then built from Format, in which the placeholders are
+// replaced:
+//
+//	{file}    the base name of the main file, e.g. main.py
+//	{name}    the base name without extension, e.g. Main
+//	{class}   the first group of the innermost match of Class whose body contains the main method, or {name} if there is none
+//	{package} the first group of Package followed by a dot, or nothing if it does not match
+//	{facade}  the Kotlin file facade class of the main file, e.g. MainKt
+type entryPointRule struct {
+	Aliases []string `mapstructure:"aliases"`
+	Main    string   `mapstructure:"main"`
+	Class   string   `mapstructure:"class"`
+	Package string   `mapstructure:"package"`
+	Format  string   `mapstructure:"format"`
+}
+
+// defaultEntryPointRules is keyed by language. A rule applies to a language when the key or one of its aliases matches
+// the id, name or an extension of the language, see languageAlias.
+var defaultEntryPointRules = map[string]entryPointRule{
+	"java": {
+		Aliases: []string{"java", "openjdk"},
+		Main:    `\bstatic\s+(?:final\s+)?void\s+main\s*\(`,
+		Class:   `\b(?:class|interface|enum|record)\s+(\w+)`,
+		Package: `(?m)^\s*package\s+([\w.]+)\s*;`,
+		Format:  "{package}{class}",
+	},
+	"kotlin": {
+		Aliases: []string{"kotlin", "kt"},
+		Main:    `\bfun\s+main\s*\(`,
+		Package: `(?m)^\s*package\s+([\w.]+)`,
+		Format:  "{package}{facade}",
+	},
+	"scala": {
+		Aliases: []string{"scala", "sc"},
+		Main:    `\bdef\s+main\s*\(|\bextends\s+App\b|@main\b`,
+		Class:   `\bobject\s+(\w+)`,
+		Package: `(?m)^\s*package\s+([\w.]+)`,
+		Format:  "{package}{class}",
+	},
+	"csharp": {
+		Aliases: []string{"csharp", "c#", "cs", "mono", "dotnet"},
+		Main:    `\bstatic\s+(?:async\s+)?(?:void|int|Task|Task<int>)\s+Main\s*\(`,
+		Class:   `\b(?:class|struct)\s+(\w+)`,
+		Package: `\bnamespace\s+([\w.]+)`,
+		Format:  "{package}{class}",
+	},
+	"python": {
+		Aliases: []string{"python", "py", "pypy"},
+		Main:    `(?m)^if\s+__name__\s*==\s*['"]__main__['"]\s*:`,
+		Format:  "{file}",
+	},
+}
+
+// detectEntryPoint tries to auto detect the entry point for the given files using the rule of the language, see
+// lookupEntryPointRule. An empty string is returned if it could not be detected.
+func detectEntryPoint(language interactor.Language, files []string) string {
+	rule, found := lookupEntryPointRule(language)
+	if !found || len(files) == 0 {
+		return ""
+	}
+
+	entry, err := rule.detect(files)
+	if err != nil {
+		fmt.Fprintf(cmdCtx.stderr, "could not detect entry point; %v\n", err)
+		return ""
+	}
+
+	return entry
+}
+
+// lookupEntryPointRule returns the rule for the language. Rules in submit.entry_points in the configuration file take
+// precedence over the default rules, and rules keyed by the language id over rules matching by alias.
+func lookupEntryPointRule(language interactor.Language) (entryPointRule, bool) {
+	configured := map[string]entryPointRule{}
+	if err := viper.UnmarshalKey("submit.entry_points", &configured); err != nil {
+		fmt.Fprintf(cmdCtx.stderr, "ignoring invalid entry point rules in configuration file; %v\n", err)
+	}
+
+	for _, rules := range []map[string]entryPointRule{configured, defaultEntryPointRules} {
+		if rule, ok := rules[strings.ToLower(language.Id)]; ok {
+			return rule, true
+		}
+	}
+
+	names := append([]string{language.Id, language.Name}, language.Extensions...)
+	for _, rules := range []map[string]entryPointRule{configured, defaultEntryPointRules} {
+		var keys []string
+		for key := range rules {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			aliases := append([]string{key}, rules[key].Aliases...)
+			for _, alias := range aliases {
+				for _, name := range names {
+					if name != "" && languageAlias(name) == languageAlias(alias) {
+						return rules[key], true
+					}
+				}
+			}
+		}
+	}
+
+	return entryPointRule{}, false
+}
+
+// languageAlias normalizes a language id or name for matching, by taking its first word in lower case without version,
+// e.g. "Java 17" and "java17" become "java", "Python 3 (pypy)" becomes "python" and "C# .NET" becomes "c#".
+func languageAlias(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	end := strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r == '#' || r == '+')
+	})
+	if end > 0 {
+		name = name[:end]
+	}
+
+	return name
+}
+
+// detect finds the main file and builds the entry point from it.
+func (r entryPointRule) detect(files []string) (string, error) {
+	var main, class, pkg *regexp.Regexp
+	for _, p := range []struct {
+		re      **regexp.Regexp
+		pattern string
+	}{{&main, r.Main}, {&class, r.Class}, {&pkg, r.Package}} {
+		if p.pattern == "" {
+			continue
+		}
+
+		re, err := regexp.Compile(p.pattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern %s; %w", p.pattern, err)
+		}
+		*p.re = re
+	}
+
+	mainFile := files[0]
+	var contents []byte
+	var mainAt = -1
+	for _, f := range files {
+		c, err := ioutil.ReadFile(f)
+		if err != nil {
+			return "", fmt.Errorf("could not read file %s; %w", f, err)
+		}
+
+		if f == files[0] {
+			contents = c
+		}
+
+		if main == nil {
+			break
+		}
+
+		if loc := main.FindIndex(c); loc != nil {
+			mainFile, contents, mainAt = f, c, loc[0]
+			break
+		}
+	}
+
+	base := filepath.Base(mainFile)
+	name := strings.Split(base, ".")[0]
+
+	className := name
+	if class != nil && mainAt >= 0 {
+		if enclosing := enclosingClass(class, contents, mainAt); enclosing != "" {
+			className = enclosing
+		}
+	}
+
+	var packageName string
+	if pkg != nil {
+		if match := pkg.FindSubmatch(contents); len(match) > 1 {
+			packageName = string(match[1]) + "."
+		}
+	}
+
+	format := r.Format
+	if format == "" {
+		format = "{file}"
+	}
+
+	return strings.NewReplacer(
+		"{file}", base,
+		"{name}", name,
+		"{class}", className,
+		"{package}", packageName,
+		"{facade}", kotlinBaseEntryPoint(name)+"Kt",
+	).Replace(format), nil
+}
+
+// enclosingClass returns the first group of the innermost match of class whose body is still open at mainAt, which
+// skips classes that are declared and closed before the main method, such as nested helper classes. Braces are counted
+// without regard for strings and comments.
+func enclosingClass(class *regexp.Regexp, contents []byte, mainAt int) string {
+	matches := class.FindAllSubmatchIndex(contents[:mainAt], -1)
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+		if len(m) < 4 || m[2] < 0 {
+			continue
+		}
+
+		depth, closed := 0, false
+		for _, c := range contents[m[1]:mainAt] {
+			if c == '{' {
+				depth++
+			} else if c == '}' {
+				depth--
+				if depth <= 0 {
+					closed = true
+					break
+				}
+			}
+		}
+
+		if !closed {
+			return string(contents[m[2]:m[3]])
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguageAlias(t *testing.T) {
+	testcases := map[string]string{
+		"java":            "java",
+		"Java 17":         "java",
+		"java17":          "java",
+		"Python 3 (pypy)": "python",
+		"python3":         "python",
+		"C#":              "c#",
+		"C# .NET":         "c#",
+		"C++":             "c++",
+		"kt":              "kt",
+	}
+
+	for input, expected := range testcases {
+		t.Run(input, func(t *testing.T) {
+			assert.Equal(t, expected, languageAlias(input))
+		})
+	}
+}
+
+func TestDetectEntryPoint(t *testing.T) {
+	testcases := []struct {
+		name     string
+		language interactor.Language
+		files    map[string]string
+		order    []string
+		expected string
+	}{
+		{
+			name:     "java main class",
+			language: interactor.Language{Id: "Java 17"},
+			files: map[string]string{
+				"Util.java": "class Util {}",
+				"Main.java": "package solution;\n\nclass Helper {}\n\npublic class Solver {\n  public static void main(String[] args) {}\n}\n",
+			},
+			order:    []string{"Util.java", "Main.java"},
+			expected: "solution.Solver",
+		},
+		{
+			name:     "java nested class before main",
+			language: interactor.Language{Id: "java"},
+			files: map[string]string{
+				"Main.java": "public class Main {\n  static class Pair { int a, b; }\n\n  public static void main(String[] args) {}\n}\n",
+			},
+			order:    []string{"Main.java"},
+			expected: "Main",
+		},
+		{
+			name:     "java without main",
+			language: interactor.Language{Id: "java"},
+			files:    map[string]string{"A.java": "class A {}"},
+			order:    []string{"A.java"},
+			expected: "A",
+		},
+		{
+			name:     "kotlin by extension",
+			language: interactor.Language{Id: "k", Extensions: []string{"kt"}},
+			files: map[string]string{
+				"util.kt":  "fun helper() = 1",
+				"hello.kt": "package a.b\n\nfun main() {}\n",
+			},
+			order:    []string{"util.kt", "hello.kt"},
+			expected: "a.b.HelloKt",
+		},
+		{
+			name:     "scala object",
+			language: interactor.Language{Id: "scala"},
+			files:    map[string]string{"a.scala": "object Solution {\n  def main(args: Array[String]): Unit = {}\n}\n"},
+			order:    []string{"a.scala"},
+			expected: "Solution",
+		},
+		{
+			name:     "c sharp main",
+			language: interactor.Language{Id: "cs", Name: "C#"},
+			files:    map[string]string{"a.cs": "namespace Contest {\n  class Program {\n    static void Main(string[] args) {}\n  }\n}\n"},
+			order:    []string{"a.cs"},
+			expected: "Contest.Program",
+		},
+		{
+			name:     "c sharp nested struct before main",
+			language: interactor.Language{Id: "csharp"},
+			files:    map[string]string{"a.cs": "class Program {\n  struct Edge { public int To; }\n  static void Main() {}\n}\n"},
+			order:    []string{"a.cs"},
+			expected: "Program",
+		},
+		{
+			name:     "python main file",
+			language: interactor.Language{Id: "python3"},
+			files: map[string]string{
+				"lib.py":  "def solve(): pass",
+				"main.py": "import lib\n\nif __name__ == \"__main__\":\n    lib.solve()\n",
+			},
+			order:    []string{"lib.py", "main.py"},
+			expected: "main.py",
+		},
+		{
+			name:     "python first file",
+			language: interactor.Language{Id: "pypy3"},
+			files:    map[string]string{"a.py": "print(1)", "b.py": "print(2)"},
+			order:    []string{"a.py", "b.py"},
+			expected: "a.py",
+		},
+		{
+			name:     "unknown language",
+			language: interactor.Language{Id: "cpp"},
+			files:    map[string]string{"a.cpp": "int main() {}"},
+			order:    []string{"a.cpp"},
+			expected: "",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+
+			var files []string
+			for _, name := range tc.order {
+				files = append(files, filepath.Join(dir, name))
+			}
+
+			assert.Equal(t, tc.expected, detectEntryPoint(tc.language, files))
+		})
+	}
+}
+
+func TestDetectEntryPointConfigured(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.java": "class A { public static void main(String[] a) {} }", "prog.rb": "puts 1"})
+
+	setViper(t, "submit.entry_points", map[string]interface{}{
+		"java": map[string]interface{}{"format": "{name}"},
+		"ruby": map[string]interface{}{"aliases": []string{"rb"}, "format": "{file}"},
+	})
+
+	assert.Equal(t, "a", detectEntryPoint(interactor.Language{Id: "java"}, []string{filepath.Join(dir, "a.java")}))
+	assert.Equal(t, "prog.rb", detectEntryPoint(interactor.Language{Id: "Ruby 3"}, []string{filepath.Join(dir, "prog.rb")}))
+}
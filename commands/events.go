@@ -0,0 +1,355 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	eventFeedMinBackoff = time.Second
+	eventFeedMaxBackoff = 30 * time.Second
+)
+
+// eventSummaryFields lists, per event type, which fields of the event data are shown in the human summary.
+var eventSummaryFields = map[string][]string{
+	"contests":        {"name", "start_time"},
+	"state":           {"started", "frozen", "ended", "finalized", "end_of_updates"},
+	"problems":        {"label", "name"},
+	"languages":       {"name"},
+	"judgement-types": {"name"},
+	"teams":           {"name"},
+	"submissions":     {"team_id", "problem_id", "language_id", "contest_time"},
+	"judgements":      {"submission_id", "judgement_type_id", "end_contest_time"},
+	"runs":            {"judgement_id", "ordinal", "judgement_type_id"},
+	"clarifications":  {"from_team_id", "to_team_id", "reply_to_id", "problem_id", "text"},
+	"awards":          {"citation"},
+}
+
+var eventsCommand = &cobra.Command{
+	Use:   "events",
+	Short: "Follow the contest event feed",
+	Long: `Follow the contest event feed
+
+Events are printed as a summary line per event, or as NDJSON when using --output json. When the connection drops,
+the feed is resumed from the last received event.`,
+	Args:    cobra.NoArgs,
+	RunE:    followEvents,
+	PreRunE: configHelper("baseurl"),
+}
+
+type (
+	// event is a single event from the event feed. Both the 2020-03 format (id is the event id, op is set) and the
+	// 2022-07 format (id is the object id, token is set) are supported.
+	event struct {
+		Id    string          `json:"id"`
+		Type  string          `json:"type"`
+		Op    string          `json:"op,omitempty"`
+		Token string          `json:"token,omitempty"`
+		Data  json.RawMessage `json:"data"`
+	}
+
+	// eventFeed follows the event feed of a contest, reconnecting when the stream drops.
+	eventFeed struct {
+		api       rawApi
+		contestId string
+		types     []string
+
+		// since is the token (or for older servers, the event id) of the last received event
+		since   string
+		sinceId bool
+	}
+
+	// eventHandlerError wraps errors returned by the event handler, to distinguish them from connection errors.
+	eventHandlerError struct {
+		err error
+	}
+)
+
+func (e eventHandlerError) Error() string {
+	return e.err.Error()
+}
+
+func followEvents(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if outputFormat != outputTable && outputFormat != outputJSON {
+		return fmt.Errorf("events only support the %s and %s output formats", outputTable, outputJSON)
+	}
+
+	feed, err := newEventFeed()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	feed.types = eventsTypes
+	if eventsSince != "" {
+		feed.resumeAfter(ctx, eventsSince)
+	}
+
+	return feed.follow(ctx, func(ev event, raw []byte) error {
+		if machineOutput() {
+			_, err := fmt.Fprintf(cmdCtx.stdout, "%s\n", raw)
+			return err
+		}
+
+		_, err := fmt.Fprintln(cmdCtx.stdout, eventSummary(ev))
+		return err
+	})
+}
+
+// newEventFeed creates an event feed for the contest currently configured.
+func newEventFeed() (*eventFeed, error) {
+	api, err := contestApi()
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the server; %w", err)
+	}
+
+	contest, err := api.Contest()
+	if err != nil {
+		return nil, fmt.Errorf("could not get contest; %w", err)
+	}
+
+	raw, err := newRawApi()
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventFeed{
+		api:       raw,
+		contestId: contest.Id,
+	}, nil
+}
+
+// resumeAfter makes the feed start after the event with the given token or id. Servers implementing a Contest API
+// before 2021-11 do not know tokens, so the version the server reports determines which of the two is sent.
+func (f *eventFeed) resumeAfter(ctx context.Context, since string) {
+	var info struct {
+		Version string `json:"version"`
+	}
+	if err := f.api.getJSON(ctx, "", &info); err != nil {
+		fmt.Fprintf(cmdCtx.stderr, "could not get the API version, assuming %s is an event id; %v\n", since, err)
+	}
+
+	f.since, f.sinceId = since, info.Version < "2021-11"
+}
+
+// follow streams events to handle until the context is cancelled, the handler returns an error or the server signals
+// the end of updates. Dropped connections are retried with an exponential backoff, but errors that will not go away by
+// trying again, such as invalid credentials or an unknown contest, are returned.
+func (f *eventFeed) follow(ctx context.Context, handle func(ev event, raw []byte) error) error {
+	backoff := eventFeedMinBackoff
+	for {
+		received, done, err := f.stream(ctx, handle)
+		if ctx.Err() != nil || done {
+			return nil
+		}
+
+		var handlerErr eventHandlerError
+		if errors.As(err, &handlerErr) {
+			return handlerErr.err
+		}
+
+		var statusErr statusError
+		if errors.As(err, &statusErr) && !statusErr.transient() {
+			return err
+		}
+
+		if received > 0 {
+			backoff = eventFeedMinBackoff
+		}
+
+		if err == nil {
+			err = errors.New("connection closed")
+		}
+		fmt.Fprintf(cmdCtx.stderr, "Event feed interrupted (%v), reconnecting in %v\n", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > eventFeedMaxBackoff {
+			backoff = eventFeedMaxBackoff
+		}
+	}
+}
+
+func (f *eventFeed) path() string {
+	query := url.Values{}
+	if len(f.types) > 0 {
+		query.Set("types", strings.Join(f.types, ","))
+	}
+
+	if f.since != "" {
+		if f.sinceId {
+			query.Set("since_id", f.since)
+		} else {
+			query.Set("since_token", f.since)
+		}
+	}
+
+	path := "contests/" + url.PathEscape(f.contestId) + "/event-feed"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	return path
+}
+
+// stream reads a single connection to the event feed. It returns the number of events received and whether the end
+// of updates has been reached.
+func (f *eventFeed) stream(ctx context.Context, handle func(ev event, raw []byte) error) (int, bool, error) {
+	resp, err := f.api.get(ctx, f.path())
+	if err != nil {
+		return 0, false, err
+	}
+
+	defer resp.Body.Close()
+
+	var received int
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var ev event
+			if jsonErr := json.Unmarshal(line, &ev); jsonErr != nil {
+				return received, false, fmt.Errorf("could not decode event; %w", jsonErr)
+			}
+
+			received++
+			if ev.Token != "" {
+				f.since, f.sinceId = ev.Token, false
+			} else if ev.Id != "" && ev.Op != "" {
+				f.since, f.sinceId = ev.Id, true
+			}
+
+			if f.wants(ev.Type) {
+				if handlerErr := handle(ev, line); handlerErr != nil {
+					return received, false, eventHandlerError{handlerErr}
+				}
+			}
+
+			if ev.endOfUpdates() {
+				return received, true, nil
+			}
+		}
+
+		if err == io.EOF {
+			return received, false, nil
+		} else if err != nil {
+			return received, false, err
+		}
+	}
+}
+
+// wants returns whether events of the given type should be handled. The types are also sent to the server, but not
+// all servers support filtering.
+func (f *eventFeed) wants(eventType string) bool {
+	if len(f.types) == 0 {
+		return true
+	}
+
+	for _, t := range f.types {
+		if strings.EqualFold(t, eventType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deleted returns whether the event signals the removal of an object.
+func (ev event) deleted() bool {
+	return ev.Op == "delete" || len(ev.Data) == 0 || string(ev.Data) == "null"
+}
+
+// objectId returns the id of the object the event is about.
+func (ev event) objectId() string {
+	if ev.Op == "" {
+		return ev.Id
+	}
+
+	var data struct {
+		Id string `json:"id"`
+	}
+	_ = json.Unmarshal(ev.Data, &data)
+	return data.Id
+}
+
+func (ev event) endOfUpdates() bool {
+	if ev.Type != "state" || ev.deleted() {
+		return false
+	}
+
+	var state struct {
+		EndOfUpdates *string `json:"end_of_updates"`
+	}
+	_ = json.Unmarshal(ev.Data, &state)
+	return state.EndOfUpdates != nil
+}
+
+// eventSummary formats an event as a single human readable line.
+func eventSummary(ev event) string {
+	op := ev.Op
+	if op == "" {
+		op = "update"
+		if ev.deleted() {
+			op = "delete"
+		}
+	}
+
+	line := fmt.Sprintf("%-16s %-7s %s", ev.Type, op, ev.objectId())
+	if ev.deleted() {
+		return line
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(ev.Data, &data); err != nil {
+		return line
+	}
+
+	fields, ok := eventSummaryFields[ev.Type]
+	if !ok {
+		for key := range data {
+			if key != "id" {
+				fields = append(fields, key)
+			}
+		}
+		sort.Strings(fields)
+	}
+
+	var details []string
+	for _, field := range fields {
+		value, ok := data[field]
+		if !ok || value == nil {
+			continue
+		}
+
+		text := strings.SplitN(fmt.Sprintf("%v", value), "\n", 2)[0]
+		details = append(details, fmt.Sprintf("%s=%s", field, text))
+	}
+
+	if len(details) == 0 {
+		return line
+	}
+
+	return line + "  " + strings.Join(details, " ")
+}
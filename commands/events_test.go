@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventFeedFollow(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Get("since_token") == "" {
+			// First connection drops after two events
+			fmt.Fprintln(w, `{"type":"problems","id":"a","data":{"id":"a","label":"A"},"token":"1"}`)
+			fmt.Fprintln(w, "")
+			fmt.Fprintln(w, `{"type":"submissions","id":"s1","data":{"id":"s1","team_id":"t1"},"token":"2"}`)
+			return
+		}
+
+		fmt.Fprintln(w, `{"type":"submissions","id":"s2","data":null,"token":"3"}`)
+		fmt.Fprintln(w, `{"type":"state","data":{"started":"2020-01-01T00:00:00Z","end_of_updates":"2020-01-01T05:00:00Z"},"token":"4"}`)
+	}))
+	defer server.Close()
+
+	feed := &eventFeed{
+		api:       rawApi{client: server.Client(), baseUrl: server.URL + "/"},
+		contestId: "finals",
+		types:     []string{"submissions"},
+	}
+
+	var summaries []string
+	err := feed.follow(context.Background(), func(ev event, raw []byte) error {
+		summaries = append(summaries, eventSummary(ev))
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"types=submissions", "since_token=2&types=submissions"}, queries)
+	assert.EqualValues(t, []string{
+		"submissions      update  s1  team_id=t1",
+		"submissions      delete  s2",
+	}, summaries)
+}
+
+func TestEventFeedResumeAfter(t *testing.T) {
+	for version, expected := range map[string]string{"2022-07": "since_token=5", "2020-03": "since_id=5", "": "since_id=5"} {
+		var query string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/" {
+				fmt.Fprintf(w, `{"version":"%s"}`, version)
+				return
+			}
+
+			query = r.URL.RawQuery
+			fmt.Fprintln(w, `{"type":"state","data":{"end_of_updates":"2020-01-01T05:00:00Z"}}`)
+		}))
+
+		feed := &eventFeed{api: rawApi{client: server.Client(), baseUrl: server.URL + "/"}, contestId: "finals"}
+		feed.resumeAfter(context.Background(), "5")
+		assert.NoError(t, feed.follow(context.Background(), func(ev event, raw []byte) error { return nil }))
+		assert.Equal(t, expected, query, version)
+		server.Close()
+	}
+}
+
+func TestEventFeedFatalStatus(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintln(w, `{"code":401,"message":"invalid username or password"}`)
+	}))
+	defer server.Close()
+
+	feed := &eventFeed{api: rawApi{client: server.Client(), baseUrl: server.URL + "/"}, contestId: "finals"}
+	err := feed.follow(context.Background(), func(ev event, raw []byte) error { return nil })
+	assert.EqualError(t, err, "invalid username or password (error code 401)")
+	assert.Equal(t, 1, requests)
+}
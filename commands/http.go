@@ -0,0 +1,169 @@
+package commands
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// rawApi can be used for the parts of the Contest API that the interactor does not support, such as the event feed
+// and file downloads. It uses the same configuration as contestApi.
+type rawApi struct {
+	client   *http.Client
+	baseUrl  string
+	username string
+	password string
+}
+
+func newRawApi() (rawApi, error) {
+	if offline {
+		return rawApi{}, errOffline
+	}
+
+	user, pass, err := credentials()
+	if err != nil {
+		return rawApi{}, err
+	}
+
+	return rawApiFor(user, pass), nil
+}
+
+// rawApiFor returns a rawApi for the configured server that authenticates with the given credentials.
+func rawApiFor(user, pass string) rawApi {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: viper.GetBool("insecure")}
+
+	return rawApi{
+		client:   &http.Client{Transport: transport},
+		baseUrl:  strings.TrimRight(viper.GetString("baseurl"), "/") + "/",
+		username: user,
+		password: pass,
+	}
+}
+
+// url resolves a path relative to the base URL. Absolute URLs are returned unchanged.
+func (r rawApi) url(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+
+	return r.baseUrl + strings.TrimLeft(path, "/")
+}
+
+// sameOrigin returns whether the URL has the scheme and host of the base URL.
+func (r rawApi) sameOrigin(u *url.URL) bool {
+	base, err := url.Parse(r.baseUrl)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(u.Scheme, base.Scheme) && strings.EqualFold(u.Host, base.Host)
+}
+
+// get performs a GET request for the given path. The caller is responsible for closing the body of the response.
+func (r rawApi) get(ctx context.Context, path string) (*http.Response, error) {
+	resp, _, err := r.getModifiedSince(ctx, path, time.Time{})
+	return resp, err
+}
+
+// getModifiedSince performs a GET request for the given path, which is conditional if since is not zero. When the
+// server reports that the resource is not modified, no response is returned. The caller is responsible for closing the
+// body of the response.
+func (r rawApi) getModifiedSince(ctx context.Context, path string, since time.Time) (*http.Response, bool, error) {
+	var lastModified string
+	if !since.IsZero() {
+		lastModified = since.UTC().Format(http.TimeFormat)
+	}
+
+	return r.getConditional(ctx, path, "", lastModified)
+}
+
+// getConditional performs a GET request for the given path, which is conditional on the ETag and Last-Modified header
+// of an earlier response if they are not empty. When the server reports that the resource is not modified, no
+// response is returned. The caller is responsible for closing the body of the response.
+func (r rawApi) getConditional(ctx context.Context, path, etag, lastModified string) (*http.Response, bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url(path), nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Hrefs may point to another host, which must not receive the credentials
+	if r.username != "" && r.password != "" && r.sameOrigin(request.URL) {
+		request.SetBasicAuth(r.username, r.password)
+	}
+
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		request.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := r.client.Do(request)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, false, responseError(resp)
+	}
+
+	return resp, true, nil
+}
+
+// statusError is the error for an unsuccessful response.
+type statusError struct {
+	statusCode int
+	message    string
+}
+
+func (e statusError) Error() string {
+	return e.message
+}
+
+// transient returns whether the request may succeed when it is tried again later.
+func (e statusError) transient() bool {
+	return e.statusCode == http.StatusRequestTimeout || e.statusCode == http.StatusTooManyRequests || e.statusCode >= 500
+}
+
+// responseError returns the error for an unsuccessful response, using the message of the server if it sent one.
+func responseError(resp *http.Response) error {
+	var e struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&e); err == nil && e.Message != "" {
+		return statusError{resp.StatusCode, fmt.Sprintf("%s (error code %d)", e.Message, resp.StatusCode)}
+	}
+
+	return statusError{resp.StatusCode, fmt.Sprintf("unexpected status code %d for %s", resp.StatusCode, resp.Request.URL)}
+}
+
+// getJSON performs a GET request for the given path and decodes the JSON response into v.
+func (r rawApi) getJSON(ctx context.Context, path string, v interface{}) error {
+	resp, err := r.get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("could not decode response; %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawApiCredentials(t *testing.T) {
+	authorized := map[string]bool{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, ok := r.BasicAuth()
+		authorized[r.Host+r.URL.Path] = ok
+		w.Write([]byte("{}"))
+	})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	other := httptest.NewServer(handler)
+	defer other.Close()
+
+	raw := rawApi{client: server.Client(), baseUrl: server.URL + "/api/", username: "team1", password: "secret"}
+	var v struct{}
+	assert.NoError(t, raw.getJSON(context.Background(), "contests", &v))
+	assert.NoError(t, raw.getJSON(context.Background(), server.URL+"/files/a.pdf", &v))
+	assert.NoError(t, raw.getJSON(context.Background(), other.URL+"/files/a.pdf", &v))
+
+	// Only the server that was configured receives the credentials
+	assert.Equal(t, map[string]bool{
+		server.Listener.Addr().String() + "/api/contests": true,
+		server.Listener.Addr().String() + "/files/a.pdf":  true,
+		other.Listener.Addr().String() + "/files/a.pdf":   false,
+	}, authorized)
+}
@@ -4,25 +4,51 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var loginCommand = &cobra.Command{
-	Use:                   "login [username] [password]",
-	Short:                 "Set login credentials",
-	Args:                  cobra.ExactValidArgs(2),
-	DisableFlagsInUseLine: true,
-	RunE:                  login,
+	Use:   "login [username] [password]",
+	Short: "Set login credentials",
+	Long: `Set login credentials
+
+The username is stored in the active profile and the password in a credential store. By default this is the keyring
+of the operating system if available, otherwise a file in the configuration folder encrypted with a passphrase (which
+is read from the ICPC_PASSPHRASE environment variable or prompted for). Use --store=plaintext to store the password
+in the configuration file instead.`,
+	Args: cobra.ExactValidArgs(2),
+	RunE: login,
 }
 
 func login(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
-	viper.Set("username", args[0])
-	viper.Set("password", args[1])
-	if err := viper.WriteConfigAs(configFile()); err != nil {
+	name := credentialStoreName
+	if name == "" {
+		name = defaultCredentialStore()
+	}
+
+	store, err := credentialStoreByName(name)
+	if err != nil {
+		return err
+	}
+	if !store.available() {
+		return fmt.Errorf("the %s credential store is not available on this system", name)
+	}
+
+	// Remove the password of a previous login, so it can not linger in another store
+	if err := eraseCredentials(stringSetting(profileSettings(activeProfile), "username")); err != nil {
+		return err
+	}
+
+	if err := updateProfile(map[string]interface{}{"username": args[0], credentialStoreKey: name}); err != nil {
 		return err
 	}
 
-	fmt.Printf("Credentials set for %s\n", args[0])
+	key := credentialKey(activeProfile, args[0])
+	defer forgetStoredPassword(key)
+	if err := store.set(key, args[1]); err != nil {
+		return fmt.Errorf("could not store password in the %s credential store; %w", name, err)
+	}
+
+	fmt.Fprintf(cmdCtx.stdout, "Credentials set for %s in profile %s, password stored in the %s credential store\n", args[0], activeProfile, name)
 	return nil
 }
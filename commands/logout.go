@@ -16,12 +16,38 @@ var logoutCommand = &cobra.Command{
 
 func logout(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
-	viper.Set("username", "")
-	viper.Set("password", "")
-	if err := viper.WriteConfigAs(configFile()); err != nil {
+	if err := eraseCredentials(stringSetting(profileSettings(activeProfile), "username")); err != nil {
 		return err
 	}
 
-	fmt.Printf("Successfully written config to %s\n", configFile())
+	delete(profileSettings(activeProfile), credentialStoreKey)
+	viper.Set(credentialStoreKey, "")
+	if err := updateProfile(map[string]interface{}{"username": ""}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmdCtx.stdout, "Removed credentials of profile %s\n", activeProfile)
+	return nil
+}
+
+// eraseCredentials removes the password of the user from every available credential store, so it does not matter
+// which store holds it.
+func eraseCredentials(user string) error {
+	key := credentialKey(activeProfile, user)
+	defer forgetStoredPassword(key)
+	for _, name := range credentialStores {
+		store, err := credentialStoreByName(name)
+		if err != nil {
+			return err
+		}
+		if !store.available() {
+			continue
+		}
+
+		if err := store.erase(key); err != nil {
+			return fmt.Errorf("could not remove password from the %s credential store; %w", name, err)
+		}
+	}
+
 	return nil
 }
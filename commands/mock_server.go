@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/icpctools/cli/mockccs"
+	"github.com/spf13/cobra"
+)
+
+var mockServerCommand = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Run a local contest server to practice with",
+	Long: `Run a local contest server to practice with
+
+Serves the Contest API for the contest package in --dir (contest.yaml, problemset.yaml, teams, accounts, languages
+and judgement-types), so the other commands can be tried without network. Submissions are not run but judged after
+--judge-delay with the --verdict of their problem, or AC. A file can ask for a verdict with a comment such as
+"@EXPECTED_RESULTS@: WA". Clarifications of teams are answered automatically.
+
+When the package has no accounts, every team can log in with team<id> as username and password, and judges can log in
+with admin as username and password. Point the other commands at the server with e.g.:
+
+  contest --baseurl http://localhost:8080 --username team1 --password team1 submit hello.cpp`,
+	Args: cobra.NoArgs,
+	RunE: runMockServer,
+}
+
+func runMockServer(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	server, err := mockccs.Load(mockServerDir)
+	if err != nil {
+		return err
+	}
+
+	server.Verdicts = mockServerVerdicts
+	server.DefaultVerdict = mockServerDefaultVerdict
+	server.JudgeDelay = mockServerJudgeDelay
+
+	listener, err := net.Listen("tcp", mockServerListen)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s; %w", mockServerListen, err)
+	}
+
+	contest := server.Contest()
+	fmt.Fprintf(cmdCtx.stdout, "Serving contest %s (%s) at http://%s\n", contest.Name, contest.Id, listener.Addr())
+	fmt.Fprintf(cmdCtx.stdout, "Contest starts at %s and lasts %s\n", contest.StartTime.Local().Format(time.RFC1123), contest.Duration.Duration())
+
+	var table = Table{}
+	table.Header = []string{"Username", "Password", "Type", "Team"}
+	table.Align = []int{ALIGN_LEFT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_LEFT}
+	for _, a := range server.Accounts() {
+		table.appendRow([]string{a.Username, a.Password, a.Type, a.TeamId})
+	}
+	printBanner("\nAccounts:\n")
+	table.print()
+	fmt.Fprintln(cmdCtx.stdout, "\nPress Ctrl+C to stop")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	httpServer := &http.Server{Handler: server}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server stopped; %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// netrcEnv can be used to read another file than ~/.netrc
+const netrcEnv = "NETRC"
+
+// netrcEntry contains the credentials of a machine in a .netrc file. The default entry has an empty machine.
+type netrcEntry struct {
+	machine  string
+	login    string
+	password string
+}
+
+// netrcFile returns the location of the .netrc file.
+func netrcFile() string {
+	if file := os.Getenv(netrcEnv); file != "" {
+		return file
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc")
+	}
+
+	return filepath.Join(home, ".netrc")
+}
+
+// netrcCredentials returns the login and password for the host of baseUrl from the .netrc file, falling back to the
+// default entry. The last return value is false if no entry is found.
+func netrcCredentials(baseUrl string) (string, string, bool) {
+	u, err := url.Parse(baseUrl)
+	if err != nil || u.Hostname() == "" {
+		return "", "", false
+	}
+
+	file := netrcFile()
+	if file == "" {
+		return "", "", false
+	}
+
+	bts, err := ioutil.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", "", false
+	} else if err != nil {
+		printBanner("ignoring %s; %v\n", file, err)
+		return "", "", false
+	}
+
+	// The entry of the host takes precedence over the default entry, wherever they appear in the file
+	var fallback *netrcEntry
+	entries := parseNetrc(string(bts))
+	for i, entry := range entries {
+		if entry.machine == u.Hostname() {
+			return entry.login, entry.password, entry.login != ""
+		} else if entry.machine == "" && fallback == nil {
+			fallback = &entries[i]
+		}
+	}
+
+	if fallback != nil {
+		return fallback.login, fallback.password, fallback.login != ""
+	}
+
+	return "", "", false
+}
+
+// parseNetrc parses the contents of a .netrc file. Macro definitions are skipped.
+func parseNetrc(contents string) []netrcEntry {
+	var entries []netrcEntry
+	var current *netrcEntry
+
+	lines := strings.Split(contents, "\n")
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		for j := 0; j < len(fields); j++ {
+			if strings.HasPrefix(fields[j], "#") {
+				break
+			}
+
+			var value string
+			if j+1 < len(fields) {
+				value = fields[j+1]
+			}
+
+			switch fields[j] {
+			case "machine":
+				entries = append(entries, netrcEntry{machine: value})
+				current = &entries[len(entries)-1]
+				j++
+			case "default":
+				entries = append(entries, netrcEntry{})
+				current = &entries[len(entries)-1]
+			case "login":
+				if current != nil {
+					current.login = value
+				}
+				j++
+			case "password":
+				if current != nil {
+					current.password = value
+				}
+				j++
+			case "account":
+				j++
+			case "macdef":
+				// A macro definition ends at the first empty line
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				j = len(fields)
+			}
+		}
+	}
+
+	return entries
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNetrc(t *testing.T) {
+	contents := `# credentials
+machine ccs.example.com
+  login team47
+  password mn3r0f
+
+macdef init
+  cd /pub
+  machine not.a.machine
+
+machine other.example.com login admin password secret account acc
+default login anonymous password guest
+`
+
+	assert.Equal(t, []netrcEntry{
+		{machine: "ccs.example.com", login: "team47", password: "mn3r0f"},
+		{machine: "other.example.com", login: "admin", password: "secret"},
+		{login: "anonymous", password: "guest"},
+	}, parseNetrc(contents))
+}
+
+func TestNetrcCredentials(t *testing.T) {
+	// The default entry comes first, but only applies to hosts without their own entry
+	file := filepath.Join(t.TempDir(), "netrc")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("default login anonymous password guest\nmachine ccs.example.com login team47 password mn3r0f\n"), 0600))
+
+	old, had := os.LookupEnv(netrcEnv)
+	os.Setenv(netrcEnv, file)
+	defer func() {
+		if had {
+			os.Setenv(netrcEnv, old)
+		} else {
+			os.Unsetenv(netrcEnv)
+		}
+	}()
+
+	tests := []struct {
+		baseUrl string
+		login   string
+		pass    string
+		found   bool
+	}{
+		{"https://ccs.example.com:8443/api", "team47", "mn3r0f", true},
+		{"https://other.example.com/api", "anonymous", "guest", true},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.baseUrl, func(t *testing.T) {
+			login, pass, found := netrcCredentials(tt.baseUrl)
+			assert.Equal(t, tt.login, login)
+			assert.Equal(t, tt.pass, pass)
+			assert.Equal(t, tt.found, found)
+		})
+	}
+}
+
+func TestCredentialsFallBackToNetrc(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "netrc")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("machine ccs.example.com login team47 password mn3r0f\n"), 0600))
+
+	old, had := os.LookupEnv(netrcEnv)
+	os.Setenv(netrcEnv, file)
+	defer func() {
+		if had {
+			os.Setenv(netrcEnv, old)
+		} else {
+			os.Unsetenv(netrcEnv)
+		}
+	}()
+	setViper(t, "baseurl", "https://ccs.example.com/api")
+	user, pass, err := credentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "team47", user)
+	assert.Equal(t, "mn3r0f", pass)
+
+	setViper(t, "username", "team48")
+	setViper(t, "password", "secret")
+	user, pass, err = credentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "team48", user)
+	assert.Equal(t, "secret", pass)
+}
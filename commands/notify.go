@@ -0,0 +1,357 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	notificationClarification = "clarification"
+	notificationJudgement     = "judgement"
+
+	// notifyHookKey is the configuration setting holding the hook command
+	notifyHookKey = "notify.hook"
+)
+
+var notifyCommand = &cobra.Command{
+	Use:   "notify",
+	Short: "Notify about new clarifications and judgements",
+	Long: `Notify about new clarifications and judgements
+
+Watches the event feed (or polls the API when using --poll) until interrupted, and notifies about every new
+clarification and every judgement of the submissions of your team. Notifications ring the terminal bell and set the
+terminal title, are shown on the desktop when notify-send or gdbus is available, and are passed to the hook command
+when one is given with --hook or configured in the configuration file:
+
+  notify:
+    hook: ~/bin/on-contest-event
+
+The hook is run by the shell and receives the notification as JSON on its standard input, e.g.:
+
+  {"type":"judgement","id":"j12","title":"Problem A: Accepted","message":"Submission 42 for problem A: Accepted","data":{...}}`,
+	Args:    cobra.NoArgs,
+	RunE:    notify,
+	PreRunE: configHelper("baseurl"),
+}
+
+type (
+	// notification is sent to all notifiers, and as JSON to the hook command.
+	notification struct {
+		Type    string      `json:"type"`
+		Id      string      `json:"id"`
+		Title   string      `json:"title"`
+		Message string      `json:"message"`
+		Data    interface{} `json:"data"`
+	}
+
+	// notifier decides which clarifications and judgements are new, and sends notifications about them.
+	notifier struct {
+		teamId         string
+		problems       problemSet
+		judgementTypes judgementTypeSet
+		submissions    map[string]interactor.Submission
+
+		// notified contains the clarifications and judgements that have been notified about, or existed on startup
+		notified map[string]bool
+		send     func(n notification)
+	}
+)
+
+func notify(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	api, err := contestApi()
+	if err != nil {
+		return fmt.Errorf("could not connect to the server; %w", err)
+	}
+
+	problems, err := api.Problems()
+	if err != nil {
+		return fmt.Errorf("could not get problems; %w", err)
+	}
+
+	judgementTypes, err := api.JudgementTypes()
+	if err != nil {
+		return fmt.Errorf("could not get judgement types; %w", err)
+	}
+
+	n := &notifier{
+		problems:       problems,
+		judgementTypes: judgementTypes,
+		submissions:    map[string]interactor.Submission{},
+		notified:       map[string]bool{},
+	}
+
+	// Only judgements of the own team are of interest, but admins and the jury get notified about all of them
+	if account, err := api.Account(); err == nil {
+		n.teamId = account.TeamId
+	}
+
+	hook := notifyHook
+	if hook == "" {
+		hook = viper.GetString(notifyHookKey)
+	}
+
+	n.send = func(notif notification) {
+		notifyTerminal(notif)
+		if notifyDesktop {
+			if err := notifyDesktopNotification(notif); err != nil {
+				fmt.Fprintf(cmdCtx.stderr, "could not show desktop notification; %v\n", err)
+			}
+		}
+		if hook != "" {
+			if err := notifyHookCommand(hook, notif); err != nil {
+				fmt.Fprintf(cmdCtx.stderr, "could not run hook; %v\n", err)
+			}
+		}
+	}
+
+	// Everything that already exists is not new
+	if err := n.poll(api, false); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	printBanner("Watching for new clarifications and judgements, press Ctrl-C to stop\n")
+	if notifyPoll > 0 {
+		return n.pollEvery(ctx, api, notifyPoll)
+	}
+
+	feed, err := newEventFeed()
+	if err != nil {
+		return err
+	}
+
+	feed.types = []string{"submissions", "judgements", "clarifications"}
+	return feed.follow(ctx, n.handleEvent)
+}
+
+// pollEvery polls the API until the context is cancelled.
+func (n *notifier) pollEvery(ctx context.Context, api interactor.ContestApi, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := n.poll(api, true); err != nil {
+				fmt.Fprintf(cmdCtx.stderr, "%v, retrying in %v\n", err, interval)
+			}
+		}
+	}
+}
+
+// poll retrieves all clarifications, submissions and judgements, notifying about the new ones if send is true.
+func (n *notifier) poll(api interactor.ContestApi, send bool) error {
+	clars, err := api.Clarifications()
+	if err != nil {
+		return fmt.Errorf("could not retrieve clarifications; %w", err)
+	}
+
+	submissions, err := api.Submissions()
+	if err != nil {
+		return fmt.Errorf("could not get submissions; %w", err)
+	}
+
+	judgements, err := api.Judgements()
+	if err != nil {
+		return fmt.Errorf("could not get judgements; %w", err)
+	}
+
+	for _, s := range submissions {
+		n.submissions[s.Id] = s
+	}
+
+	for _, c := range clars {
+		n.clarification(c, send)
+	}
+
+	for _, j := range judgements {
+		n.judgement(j, send)
+	}
+
+	return nil
+}
+
+// handleEvent processes a single event from the event feed. Events that can not be decoded are skipped, so the feed
+// keeps being followed.
+func (n *notifier) handleEvent(ev event, raw []byte) error {
+	if ev.deleted() {
+		return nil
+	}
+
+	switch ev.Type {
+	case "submissions":
+		var s interactor.Submission
+		if err := json.Unmarshal(ev.Data, &s); err != nil {
+			fmt.Fprintf(cmdCtx.stderr, "ignoring submission that could not be decoded; %v\n", err)
+			return nil
+		}
+		n.submissions[s.Id] = s
+	case "judgements":
+		var j interactor.Judgement
+		if err := json.Unmarshal(ev.Data, &j); err != nil {
+			fmt.Fprintf(cmdCtx.stderr, "ignoring judgement that could not be decoded; %v\n", err)
+			return nil
+		}
+		n.judgement(j, true)
+	case "clarifications":
+		var c interactor.Clarification
+		if err := json.Unmarshal(ev.Data, &c); err != nil {
+			fmt.Fprintf(cmdCtx.stderr, "ignoring clarification that could not be decoded; %v\n", err)
+			return nil
+		}
+		n.clarification(c, true)
+	}
+
+	return nil
+}
+
+// clarification notifies about the clarification if it is new.
+func (n *notifier) clarification(c interactor.Clarification, send bool) {
+	key := notificationClarification + "/" + c.Id
+	if n.notified[key] {
+		return
+	}
+	n.notified[key] = true
+
+	// Clarifications sent by the team itself are not worth a notification
+	if n.teamId != "" && c.FromTeamId == n.teamId {
+		return
+	}
+
+	title := "New clarification"
+	if c.FromTeamId == "" && c.ToTeamId == "" {
+		title = "Broadcast from the jury"
+	} else if c.FromTeamId == "" {
+		title = "Response from the jury"
+	}
+
+	if problem, hasProblem := n.problems.byId(c.ProblemId); c.ProblemId != "" && hasProblem {
+		title += fmt.Sprintf(" (problem %s)", problem.Label)
+	}
+
+	if send {
+		n.send(notification{
+			Type:    notificationClarification,
+			Id:      c.Id,
+			Title:   title,
+			Message: c.Text,
+			Data:    c,
+		})
+	}
+}
+
+// judgement notifies about the judgement if it is final, new and for a submission of the team.
+func (n *notifier) judgement(j interactor.Judgement, send bool) {
+	if j.JudgementTypeId == "" {
+		return
+	}
+
+	key := notificationJudgement + "/" + j.Id
+	if n.notified[key] {
+		return
+	}
+
+	submission, hasSubmission := n.submissions[j.SubmissionId]
+	if n.teamId != "" && (!hasSubmission || submission.TeamId != n.teamId) {
+		return
+	}
+	n.notified[key] = true
+
+	verdict := j.JudgementTypeId
+	if jt, ok := n.judgementTypes.byId(j.JudgementTypeId); ok {
+		verdict = jt.Name
+	}
+
+	problem := submission.ProblemId
+	if p, ok := n.problems.byId(submission.ProblemId); ok {
+		problem = p.Label
+	}
+
+	if send {
+		n.send(notification{
+			Type:    notificationJudgement,
+			Id:      j.Id,
+			Title:   fmt.Sprintf("Problem %s: %s", problem, verdict),
+			Message: fmt.Sprintf("Submission %s for problem %s: %s", j.SubmissionId, problem, verdict),
+			Data:    j,
+		})
+	}
+}
+
+// notifyTerminal prints the notification. On a terminal it also rings the bell and sets the title, which would only
+// clutter a redirected log.
+func notifyTerminal(n notification) {
+	if isTerminal(cmdCtx.stdout) {
+		fmt.Fprintf(cmdCtx.stdout, "\a\033]0;%s\007", n.Title)
+	}
+	fmt.Fprintf(cmdCtx.stdout, "[%s] %s\n", cmdCtx.now().Format("15:04:05"), n.Title)
+	if n.Type == notificationClarification {
+		for _, line := range strings.Split(n.Message, "\n") {
+			fmt.Fprintf(cmdCtx.stdout, "  %s\n", line)
+		}
+	}
+}
+
+// notifyDesktopNotification shows the notification on the desktop through the freedesktop notification service. It is
+// not an error when no tool to reach the service is installed.
+func notifyDesktopNotification(n notification) error {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return nil
+	}
+
+	var cmd *exec.Cmd
+	if _, err := exec.LookPath("notify-send"); err == nil {
+		cmd = exec.Command("notify-send", "--app-name=contest", n.Title, n.Message)
+	} else if _, err := exec.LookPath("gdbus"); err == nil {
+		cmd = exec.Command("gdbus", "call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			"contest", "0", "", n.Title, n.Message, "[]", "{}", "-1")
+	} else {
+		return nil
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// notifyHookCommand runs the hook in the shell, with the notification as JSON on standard input.
+func notifyHookCommand(hook string, n notification) error {
+	bts, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", hook)
+	} else {
+		cmd = exec.Command("sh", "-c", hook)
+	}
+
+	cmd.Stdin = bytes.NewReader(append(bts, '\n'))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifier(t *testing.T) {
+	var sent []string
+	n := &notifier{
+		teamId:         "team1",
+		problems:       problemSet{{Id: "p1", Label: "A"}},
+		judgementTypes: judgementTypeSet{{Id: "AC", Name: "Accepted"}},
+		submissions: map[string]interactor.Submission{
+			"s1": {Id: "s1", TeamId: "team1", ProblemId: "p1"},
+			"s2": {Id: "s2", TeamId: "team2", ProblemId: "p1"},
+		},
+		notified: map[string]bool{},
+		send: func(notif notification) {
+			sent = append(sent, notif.Title)
+		},
+	}
+
+	// Existing objects are not notified about
+	n.clarification(interactor.Clarification{Id: "c1", Text: "old"}, false)
+	n.judgement(interactor.Judgement{Id: "j1", SubmissionId: "s1", JudgementTypeId: "AC"}, false)
+	n.clarification(interactor.Clarification{Id: "c1", Text: "old"}, true)
+	n.judgement(interactor.Judgement{Id: "j1", SubmissionId: "s1", JudgementTypeId: "AC"}, true)
+	assert.Empty(t, sent)
+
+	// Judgements are notified once they are final, and only for the own team
+	n.judgement(interactor.Judgement{Id: "j2", SubmissionId: "s1"}, true)
+	n.judgement(interactor.Judgement{Id: "j3", SubmissionId: "s2", JudgementTypeId: "AC"}, true)
+	n.judgement(interactor.Judgement{Id: "j2", SubmissionId: "s1", JudgementTypeId: "AC"}, true)
+	n.judgement(interactor.Judgement{Id: "j2", SubmissionId: "s1", JudgementTypeId: "AC"}, true)
+
+	// Own clarifications are not notified about
+	n.clarification(interactor.Clarification{Id: "c2", FromTeamId: "team1", ProblemId: "p1"}, true)
+	n.clarification(interactor.Clarification{Id: "c3", ToTeamId: "team1", ReplyToId: "c2", ProblemId: "p1"}, true)
+	n.clarification(interactor.Clarification{Id: "c4"}, true)
+
+	assert.Equal(t, []string{"Problem A: Accepted", "Response from the jury (problem A)", "Broadcast from the jury"}, sent)
+}
+
+func TestNotifyTerminalRedirected(t *testing.T) {
+	stdout, _ := testContext(t, "", "", time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC))
+
+	notifyTerminal(notification{Type: notificationClarification, Title: "Broadcast from the jury", Message: "Welcome"})
+	assert.Equal(t, "[10:00:00] Broadcast from the jury\n  Welcome\n", stdout.String())
+}
+
+func TestNotifyHookCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook uses a POSIX shell")
+	}
+
+	file := filepath.Join(t.TempDir(), "notification.json")
+	assert.NoError(t, notifyHookCommand("cat > "+file, notification{Type: notificationClarification, Id: "c1", Title: "Broadcast from the jury", Message: "Problem B is fixed"}))
+
+	bts, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+
+	var received notification
+	assert.NoError(t, json.Unmarshal(bts, &received))
+	assert.Equal(t, "c1", received.Id)
+	assert.Equal(t, "Problem B is fixed", received.Message)
+}
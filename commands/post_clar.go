@@ -1,15 +1,34 @@
 package commands
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
 
+	interactor "github.com/icpctools/api-interactor"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
+// editorComment is put in the file opened in the editor, lines starting with # are removed from the clarification
+const editorComment = `
+# Write your clarification above. Lines starting with # are ignored, and an
+# empty clarification aborts posting it.
+`
+
 var postClarCommand = &cobra.Command{
-	Use:     "post-clar [text]",
-	Short:   "Post a clarification",
-	Args:    cobra.ExactValidArgs(1),
+	Use:   "post-clar [text]",
+	Short: "Post a clarification",
+	Long: `Post a clarification
+
+When no text is given, the problem is asked for (unless given with --problem) and the text is written in $EDITOR, or
+read from the terminal or standard input when no editor is set.`,
+	Args:    cobra.MaximumNArgs(1),
 	RunE:    postClarification,
 	PreRunE: configHelper("baseurl"),
 }
@@ -21,27 +40,165 @@ func postClarification(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not connect to the server; %w", err)
 	}
 
-	if problemId != "" {
-		// Get the problems and languages
-		problems, err := api.Problems()
-		if err != nil {
-			return fmt.Errorf("could not get problems; %w", err)
-		}
+	// Get the problems
+	problems, err := api.Problems()
+	if err != nil {
+		return fmt.Errorf("could not get problems; %w", err)
+	}
 
-		problem, hasProblem := problemSet(problems).byId(problemId)
+	var problem interactor.Problem
+	if problemId != "" {
+		var hasProblem bool
+		problem, hasProblem = problemSet(problems).byId(problemId)
 
 		if !hasProblem {
 			return fmt.Errorf("couldn't find the problem specified")
 		}
+	} else if len(args) == 0 {
+		problem = askProblem(problems)
+	}
+
+	var text string
+	if len(args) == 1 {
+		text = args[0]
+	} else if text, err = askClarificationText(); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return errors.New("clarification aborted, no text given")
+	}
+
+	if !force {
+		fmt.Fprintln(cmdCtx.stdout, "About to post clarification:")
+		if problem.Id != "" {
+			fmt.Fprintf(cmdCtx.stdout, "  problem: %s: %s\n", problem.Label, problem.Name)
+		} else {
+			fmt.Fprintln(cmdCtx.stdout, "  problem: none (general clarification)")
+		}
+		fmt.Fprintln(cmdCtx.stdout, "  text:")
+		for _, line := range strings.Split(text, "\n") {
+			fmt.Fprintf(cmdCtx.stdout, "    %s\n", line)
+		}
 
-		problemId = problem.Id
+		if !cmdCtx.prompter.YN("Do you want to post this clarification?", true) {
+			return errors.New("clarification aborted by user")
+		}
 	}
 
-	clar, err := api.PostClarification(problemId, args[0])
+	clar, err := api.PostClarification(problem.Id, text)
 	if err != nil {
 		return fmt.Errorf("could not post clarification: %w", err)
 	}
 
-	_, err = fmt.Fprintf(cmd.OutOrStdout(), "Clarification accepted at %s\n", clar.ContestTime)
+	_, err = fmt.Fprintf(cmdCtx.stdout, "Clarification accepted at %s\n", clar.ContestTime)
 	return err
 }
+
+// askProblem asks which problem the clarification is about, until a known problem or nothing is given. An empty problem
+// is returned for a general clarification.
+func askProblem(problems []interactor.Problem) interactor.Problem {
+	var labels []string
+	for _, problem := range problems {
+		labels = append(labels, problem.Label)
+	}
+
+	message := fmt.Sprintf("What problem is your clarification related to? [%s (Enter for none)]", strings.Join(labels, "/"))
+	for {
+		answer := strings.TrimSpace(cmdCtx.prompter.Prompt(message, ""))
+		if answer == "" {
+			return interactor.Problem{}
+		}
+
+		if problem, hasProblem := problemSet(problems).byId(answer); hasProblem {
+			return problem
+		}
+
+		fmt.Fprintf(cmdCtx.stdout, "Unknown problem %s\n", answer)
+	}
+}
+
+// askClarificationText opens $VISUAL or $EDITOR to write the clarification when running in a terminal, and otherwise
+// reads it until the end of the input.
+func askClarificationText() (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" || !stdinIsTerminal() {
+		if stdinIsTerminal() {
+			fmt.Fprintln(cmdCtx.stdout, "What is your clarification? (finish with Ctrl-D on an empty line)")
+		}
+
+		return readText(os.Stdin)
+	}
+
+	return editText(editor)
+}
+
+// editText lets the user write a text in the editor, and returns it without comment lines.
+func editText(editor string) (string, error) {
+	f, err := ioutil.TempFile("", "clarification-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("could not create file to edit; %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(editorComment)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not create file to edit; %w", err)
+	}
+
+	// The editor may contain arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	c := exec.Command(fields[0], append(fields[1:], f.Name())...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("could not run editor %s; %w", editor, err)
+	}
+
+	bts, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(bts), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// readText reads all lines until the end of the input.
+func readText(r io.Reader) (string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("could not read clarification; %w", err)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// stdinIsTerminal returns whether the user can be prompted for input.
+func stdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// isTerminal returns whether w writes to a terminal rather than a file or pipe.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
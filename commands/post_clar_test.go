@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadText(t *testing.T) {
+	text, err := readText(strings.NewReader("Can we assume x is never 0?\r\n\r\nAnd y?\n\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Can we assume x is never 0?\n\nAnd y?", text)
+}
+
+func TestEditText(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("editor script requires a POSIX shell")
+	}
+
+	// The editor keeps the comment lines and adds the clarification above them
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	script := "#!/bin/sh\nprintf 'Is the input sorted?\\n%s' \"$(cat \"$1\")\" > \"$1\"\n"
+	assert.NoError(t, ioutil.WriteFile(editor, []byte(script), 0755))
+
+	text, err := editText(editor)
+	assert.NoError(t, err)
+	assert.Equal(t, "Is the input sorted?", text)
+}
@@ -33,7 +33,7 @@ func fetchProblems(cmd *cobra.Command, args []string) error {
 	})
 
 	// output
-	fmt.Printf("\nProblems (%d):\n", len(p))
+	printBanner("\nProblems (%d):\n", len(p))
 
 	var table = Table{}
 	table.Header = []string{"Label", "Name"}
@@ -0,0 +1,298 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/spf13/cobra"
+)
+
+// samplePrefix is the directory containing the sample data in a problem package
+const samplePrefix = "data/sample/"
+
+var problemDownloadCommand = &cobra.Command{
+	Use:   "download [label...]",
+	Short: "Download problem statements and samples",
+	Long: `Download problem statements and samples
+
+The statement and the samples from the problem package of every given problem (or all problems when none are given)
+are saved as <label>/statement.pdf and <label>/samples/1.in, <label>/samples/1.ans, etc. Files that did not change
+since the previous download are skipped.`,
+	RunE:    downloadProblems,
+	PreRunE: configHelper("baseurl"),
+}
+
+type (
+	// problemFiles contains the file references of a problem, which are not part of interactor.Problem
+	problemFiles struct {
+		Id        string          `json:"id"`
+		Label     string          `json:"label"`
+		Statement []fileReference `json:"statement"`
+		Package   []fileReference `json:"package"`
+	}
+
+	fileReference struct {
+		Href     string `json:"href"`
+		Mime     string `json:"mime"`
+		Filename string `json:"filename"`
+	}
+)
+
+// statementExtensions maps statement mime types to the extension to save them with
+var statementExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"text/html":       ".html",
+	"text/plain":      ".txt",
+	"text/markdown":   ".md",
+}
+
+func downloadProblems(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	api, err := contestApi()
+	if err != nil {
+		return fmt.Errorf("could not connect to the server; %w", err)
+	}
+
+	contest, err := api.Contest()
+	if err != nil {
+		return fmt.Errorf("could not get contest; %w", err)
+	}
+
+	problems, err := api.Problems()
+	if err != nil {
+		return fmt.Errorf("could not get problems; %w", err)
+	}
+
+	var selected []interactor.Problem
+	for _, label := range args {
+		problem, hasProblem := problemSet(problems).byId(label)
+		if !hasProblem {
+			return fmt.Errorf("unknown problem %s", label)
+		}
+		selected = append(selected, problem)
+	}
+	if len(args) == 0 {
+		selected = problems
+	}
+
+	raw, err := newRawApi()
+	if err != nil {
+		return err
+	}
+
+	var files []problemFiles
+	if err := raw.getJSON(context.Background(), "contests/"+url.PathEscape(contest.Id)+"/problems", &files); err != nil {
+		return fmt.Errorf("could not get problem files; %w", err)
+	}
+
+	for _, problem := range selected {
+		var pf problemFiles
+		for _, f := range files {
+			if f.Id == problem.Id {
+				pf = f
+			}
+		}
+
+		dir, err := problemDir(problemDownloadDir, problem)
+		if err != nil {
+			return err
+		}
+
+		if err := downloadProblem(raw, dir, pf); err != nil {
+			return fmt.Errorf("could not download problem %s; %w", problem.Label, err)
+		}
+	}
+
+	return nil
+}
+
+// problemDir returns the directory in parent named after the label of the problem. Labels come from the server, so
+// labels that are not a plain name are rejected instead of writing outside of parent.
+func problemDir(parent string, problem interactor.Problem) (string, error) {
+	label := problem.Label
+	if label == "" || label == "." || label == ".." || strings.ContainsAny(label, `/\`) || filepath.VolumeName(label) != "" {
+		return "", fmt.Errorf("problem label '%s' can not be used as a directory name", label)
+	}
+
+	return filepath.Join(parent, label), nil
+}
+
+// downloadProblem saves the statements and samples of a problem into dir.
+func downloadProblem(raw rawApi, dir string, pf problemFiles) error {
+	if len(pf.Statement) == 0 && len(pf.Package) == 0 {
+		fmt.Fprintf(cmdCtx.stdout, "%s: no statement or package available\n", dir)
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for _, statement := range pf.Statement {
+		name := "statement" + statementExtension(statement)
+		if used[name] {
+			continue
+		}
+		used[name] = true
+
+		filename := filepath.Join(dir, name)
+		written, err := downloadFile(raw, statement.Href, filename)
+		if err != nil {
+			return err
+		}
+		printDownloaded(filename, written)
+	}
+
+	for _, pkg := range pf.Package {
+		resp, err := raw.get(context.Background(), pkg.Href)
+		if err != nil {
+			return fmt.Errorf("could not download package; %w", err)
+		}
+
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("could not download package; %w", err)
+		}
+
+		if err := extractSamples(data, filepath.Join(dir, "samples")); err != nil {
+			return fmt.Errorf("could not extract samples; %w", err)
+		}
+	}
+
+	return nil
+}
+
+// downloadFile downloads href to filename, unless it has not been modified since the file was last written. It
+// returns whether the file was written.
+func downloadFile(raw rawApi, href, filename string) (bool, error) {
+	var since time.Time
+	if info, err := os.Stat(filename); err == nil {
+		since = info.ModTime()
+	}
+
+	resp, modified, err := raw.getModifiedSince(context.Background(), href, since)
+	if err != nil || !modified {
+		return false, err
+	}
+
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	var modTime time.Time
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		modTime, _ = http.ParseTime(lastModified)
+	}
+
+	return writeIfChanged(filename, data, modTime)
+}
+
+// extractSamples saves the sample data from a problem package to dir, keeping the paths below data/sample. Files that
+// would end up outside of dir are skipped.
+func extractSamples(data []byte, dir string) error {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range archive.File {
+		if !strings.HasPrefix(f.Name, samplePrefix) || f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+
+		contents, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(strings.TrimPrefix(f.Name, samplePrefix))
+		if name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			continue
+		}
+
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return err
+		}
+
+		written, err := writeIfChanged(filename, contents, f.Modified)
+		if err != nil {
+			return err
+		}
+		printDownloaded(filename, written)
+	}
+
+	return nil
+}
+
+// writeIfChanged writes data to filename, unless the file already has the same checksum and modification time. The
+// modification time of the file is set to modTime when it is not zero. It returns whether the file was written.
+func writeIfChanged(filename string, data []byte, modTime time.Time) (bool, error) {
+	if existing, err := ioutil.ReadFile(filename); err == nil && sha256.Sum256(existing) == sha256.Sum256(data) {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return false, err
+		}
+
+		if modTime.IsZero() || info.ModTime().Equal(modTime) {
+			return false, nil
+		}
+	}
+
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		return false, err
+	}
+
+	if !modTime.IsZero() {
+		if err := os.Chtimes(filename, modTime, modTime); err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+func statementExtension(statement fileReference) string {
+	mediaType, _, _ := mime.ParseMediaType(statement.Mime)
+	if extension, ok := statementExtensions[mediaType]; ok {
+		return extension
+	}
+
+	if extension := filepath.Ext(statement.Filename); extension != "" {
+		return strings.ToLower(extension)
+	}
+
+	return ".pdf"
+}
+
+func printDownloaded(filename string, written bool) {
+	if written {
+		fmt.Fprintf(cmdCtx.stdout, "%s: downloaded\n", filename)
+	} else {
+		fmt.Fprintf(cmdCtx.stdout, "%s: unchanged\n", filename)
+	}
+}
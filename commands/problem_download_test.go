@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "download")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	modified := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, contents := range map[string]string{
+		"problem.yaml":          "name: A",
+		"data/sample/1.in":      "1 2\n",
+		"data/sample/1.ans":     "3\n",
+		"data/sample/big/1.in":  "6 7\n",
+		"data/sample/../../x":   "outside",
+		"data/secret/1.in":      "4 5\n",
+		"problem_statement/a.c": "",
+	} {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Modified: modified, Method: zip.Deflate})
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(contents))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, archive.Close())
+
+	samples := filepath.Join(dir, "samples")
+	assert.NoError(t, extractSamples(buf.Bytes(), samples))
+
+	files, err := ioutil.ReadDir(samples)
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	contents, err := ioutil.ReadFile(filepath.Join(samples, "1.ans"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "3\n", string(contents))
+
+	// Samples in subdirectories do not overwrite each other
+	contents, err = ioutil.ReadFile(filepath.Join(samples, "1.in"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "1 2\n", string(contents))
+
+	contents, err = ioutil.ReadFile(filepath.Join(samples, "big", "1.in"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "6 7\n", string(contents))
+
+	_, err = os.Stat(filepath.Join(dir, "x"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Writing the same contents with the same modification time is skipped
+	written, err := writeIfChanged(filepath.Join(samples, "1.ans"), []byte("3\n"), modified)
+	assert.NoError(t, err)
+	assert.False(t, written)
+
+	written, err = writeIfChanged(filepath.Join(samples, "1.ans"), []byte("4\n"), modified)
+	assert.NoError(t, err)
+	assert.True(t, written)
+}
+
+func TestProblemDir(t *testing.T) {
+	tests := []struct {
+		label    string
+		expected string
+	}{
+		{"A", filepath.Join("problems", "A")},
+		{"hello-1", filepath.Join("problems", "hello-1")},
+		{"", ""},
+		{"..", ""},
+		{"../A", ""},
+		{"A/B", ""},
+		{`A\B`, ""},
+	}
+
+	for _, tt := range tests {
+		dir, err := problemDir("problems", interactor.Problem{Label: tt.label})
+		if tt.expected == "" {
+			assert.Error(t, err, tt.label)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, dir)
+		}
+	}
+}
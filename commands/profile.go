@@ -0,0 +1,302 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultProfile = "default"
+
+	// activeProfileKey and profilesKey are the keys in the configuration file holding the name of the active profile
+	// and all profiles
+	activeProfileKey = "profile"
+	profilesKey      = "profiles"
+)
+
+// profileKeys are the keys that used to be stored at the top level of the configuration file, before profiles existed.
+var profileKeys = []string{"baseurl", "username", "password", "contest", "insecure"}
+
+var (
+	// settings contains the contents of the configuration file
+	settings = map[string]interface{}{}
+
+	// activeProfile is the name of the profile the commands act on
+	activeProfile string
+)
+
+var profileCommand = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage connection profiles",
+	Long: `Manage connection profiles
+
+A profile holds its own base URL, credentials, contest ID, insecure flag and any other setting from the configuration
+file. The active profile is used by all commands, and changed by set url, set id, login and logout. Use the --profile
+flag to use another profile for a single command.`,
+	DisableFlagsInUseLine: true,
+}
+
+var profileAddCommand = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add a profile",
+	Long: `Add a profile
+
+The profile is initialized with the values of the [-b/--baseurl], [-c/--contest], [-i/--insecure], [-p/--password] and
+[-u/--username] flags. The password is stored in a credential store, as login does.`,
+	Args: cobra.ExactArgs(1),
+	RunE: addProfile,
+}
+
+var profileUseCommand = &cobra.Command{
+	Use:                   "use [name]",
+	Short:                 "Set the active profile",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	RunE:                  useProfile,
+}
+
+var profileListCommand = &cobra.Command{
+	Use:                   "list",
+	Short:                 "List profiles",
+	Args:                  cobra.NoArgs,
+	DisableFlagsInUseLine: true,
+	RunE:                  listProfiles,
+}
+
+var profileRemoveCommand = &cobra.Command{
+	Use:                   "remove [name]",
+	Short:                 "Remove a profile",
+	Args:                  cobra.ExactArgs(1),
+	DisableFlagsInUseLine: true,
+	RunE:                  removeProfile,
+}
+
+func addProfile(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if _, exists := profiles()[args[0]]; exists {
+		return fmt.Errorf("profile %s already exists", args[0])
+	}
+
+	profile := map[string]interface{}{}
+	for _, key := range profileKeys {
+		if f := cmd.Flag(key); f != nil && f.Changed && key != "password" {
+			profile[key] = viper.Get(key)
+		}
+	}
+
+	// The password is kept in a credential store, like login does
+	if f := cmd.Flag("password"); f != nil && f.Changed {
+		user := stringSetting(profile, "username")
+		if user == "" {
+			return errors.New("a password can only be stored for a user, add the username with -u")
+		}
+
+		name := defaultCredentialStore()
+		store, err := credentialStoreByName(name)
+		if err != nil {
+			return err
+		}
+
+		if err := store.set(credentialKey(args[0], user), viper.GetString("password")); err != nil {
+			return fmt.Errorf("could not store password in the %s credential store; %w", name, err)
+		}
+		profile[credentialStoreKey] = name
+	}
+
+	profiles()[args[0]] = profile
+	if err := writeSettings(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmdCtx.stdout, "Profile %s added, use 'profile use %s' to activate it.\n", args[0], args[0])
+	return nil
+}
+
+func useProfile(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if _, exists := profiles()[args[0]]; !exists {
+		return fmt.Errorf("unknown profile %s", args[0])
+	}
+
+	settings[activeProfileKey] = args[0]
+	if err := writeSettings(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmdCtx.stdout, "Now using profile %s.\n", args[0])
+	return nil
+}
+
+func listProfiles(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	var names []string
+	for name := range profiles() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	printBanner("\nProfiles (%d):\n", len(names))
+	var table = Table{}
+	table.Header = []string{"Active", "Name", "Base URL", "Username", "Contest"}
+	table.Align = []int{ALIGN_LEFT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_LEFT}
+	for _, name := range names {
+		profile := profileSettings(name)
+		var active string
+		if name == activeProfile {
+			active = "*"
+		}
+		table.appendRow([]string{active, name, stringSetting(profile, "baseurl"), stringSetting(profile, "username"), stringSetting(profile, "contest")})
+	}
+	table.print()
+
+	return nil
+}
+
+func removeProfile(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if _, exists := profiles()[args[0]]; !exists {
+		return fmt.Errorf("unknown profile %s", args[0])
+	}
+
+	delete(profiles(), args[0])
+	if settings[activeProfileKey] == args[0] {
+		delete(settings, activeProfileKey)
+	}
+
+	if err := writeSettings(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmdCtx.stdout, "Profile %s removed.\n", args[0])
+	return nil
+}
+
+// loadSettings reads the configuration file and makes the settings of the active profile available through viper.
+// Profiles are selected by the --profile flag, or otherwise by the profile stored in the configuration file.
+func loadSettings() error {
+	settings = map[string]interface{}{}
+	bts, err := ioutil.ReadFile(configFile())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not read configuration file; %w", err)
+	}
+
+	if err := yaml.Unmarshal(bts, &settings); err != nil {
+		return fmt.Errorf("could not parse configuration file %s; %w", configFile(), err)
+	}
+	if settings == nil {
+		settings = map[string]interface{}{}
+	}
+
+	migrateSettings()
+
+	activeProfile = profileName
+	if activeProfile == "" {
+		activeProfile = stringSetting(settings, activeProfileKey)
+	}
+	if activeProfile == "" {
+		activeProfile = defaultProfile
+	}
+
+	if _, exists := profiles()[activeProfile]; !exists && profileName != "" {
+		return fmt.Errorf("unknown profile %s", activeProfile)
+	}
+
+	// Everything but the profiles is shared, and values of the active profile take precedence over shared ones
+	effective := map[string]interface{}{}
+	for key, value := range settings {
+		if key != activeProfileKey && key != profilesKey {
+			effective[key] = value
+		}
+	}
+	for key, value := range profileSettings(activeProfile) {
+		effective[key] = value
+	}
+
+	return viper.MergeConfigMap(effective)
+}
+
+// migrateSettings moves the connection settings of configuration files written before profiles existed to the default
+// profile.
+func migrateSettings() {
+	if _, hasProfiles := settings[profilesKey]; hasProfiles {
+		return
+	}
+
+	legacy := map[string]interface{}{}
+	for _, key := range profileKeys {
+		if value, ok := settings[key]; ok {
+			legacy[key] = value
+			delete(settings, key)
+		}
+	}
+
+	if len(legacy) > 0 {
+		profiles()[defaultProfile] = legacy
+	}
+}
+
+// profiles returns all profiles from the configuration file, keyed by name.
+func profiles() map[string]interface{} {
+	p, ok := settings[profilesKey].(map[string]interface{})
+	if !ok {
+		p = map[string]interface{}{}
+		settings[profilesKey] = p
+	}
+
+	return p
+}
+
+// profileSettings returns the settings of the given profile.
+func profileSettings(name string) map[string]interface{} {
+	p, ok := profiles()[name].(map[string]interface{})
+	if !ok {
+		p = map[string]interface{}{}
+	}
+
+	return p
+}
+
+// updateProfile stores the given values in the active profile and writes the configuration file. The values are also
+// made available through viper.
+func updateProfile(values map[string]interface{}) error {
+	profile := profileSettings(activeProfile)
+	for key, value := range values {
+		profile[key] = value
+		viper.Set(key, value)
+	}
+	profiles()[activeProfile] = profile
+
+	return writeSettings()
+}
+
+func writeSettings() error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(settings); err != nil {
+		return fmt.Errorf("could not encode configuration; %w", err)
+	}
+
+	// The configuration file can contain credentials, so only the user may read it
+	if err := ioutil.WriteFile(configFile(), buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("could not write configuration file; %w", err)
+	}
+
+	return nil
+}
+
+func stringSetting(s map[string]interface{}, key string) string {
+	if value, ok := s[key]; ok && value != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return ""
+}
@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSettingsMigratesAndSelectsProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, configFolder), 0755))
+
+	// A configuration file from before profiles existed
+	assert.NoError(t, ioutil.WriteFile(configFile(), []byte("baseurl: https://practice/api\nusername: team1\nlanguage: cpp\n"), 0600))
+
+	defer func(name string) { profileName = name }(profileName)
+	profileName = ""
+	clearViperConfig(t)
+	unsetViper(t, "contest")
+
+	assert.NoError(t, loadSettings())
+	assert.EqualValues(t, defaultProfile, activeProfile)
+	assert.EqualValues(t, "https://practice/api", viper.GetString("baseurl"))
+	assert.EqualValues(t, "cpp", viper.GetString("language"))
+
+	profiles()["finals"] = map[string]interface{}{"baseurl": "https://finals/api", "contest": "wf"}
+	assert.NoError(t, updateProfile(map[string]interface{}{"contest": "practice"}))
+
+	profileName = "finals"
+	clearViperConfig(t)
+	unsetViper(t, "contest")
+	assert.NoError(t, loadSettings())
+	assert.EqualValues(t, "https://finals/api", viper.GetString("baseurl"))
+	assert.EqualValues(t, "wf", viper.GetString("contest"))
+	assert.EqualValues(t, "", viper.GetString("username"))
+	assert.EqualValues(t, "cpp", viper.GetString("language"))
+
+	profileName = ""
+	clearViperConfig(t)
+	unsetViper(t, "contest")
+	assert.NoError(t, loadSettings())
+	assert.EqualValues(t, "practice", viper.GetString("contest"))
+	assert.NotContains(t, settings, "baseurl")
+
+	profileName = "unknown"
+	assert.Error(t, loadSettings())
+}
+
+// setViper changes a setting until the end of the test. Tests must not use viper.Reset, as that also drops the flag and
+// environment bindings made in init, which the tests after it rely on.
+func setViper(t *testing.T, key string, value interface{}) {
+	unsetViper(t, key)
+	viper.Set(key, value)
+}
+
+// unsetViper removes the settings set with viper.Set, and restores them at the end of the test.
+func unsetViper(t *testing.T, keys ...string) {
+	for _, key := range keys {
+		key, previous, wasSet := key, viper.Get(key), viper.IsSet(key)
+		t.Cleanup(func() {
+			if wasSet {
+				viper.Set(key, previous)
+			} else {
+				viper.Set(key, nil)
+			}
+		})
+
+		// viper ignores settings that are nil, so the value is taken from the flags, environment and configuration again
+		viper.Set(key, nil)
+	}
+}
+
+// clearViperConfig drops the settings loadSettings read from the configuration file, now and at the end of the test.
+func clearViperConfig(t *testing.T) {
+	clear := func() {
+		if err := viper.ReadConfig(strings.NewReader("")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clear()
+	t.Cleanup(clear)
+}
+
+func TestAddProfileStoresPassword(t *testing.T) {
+	dir := t.TempDir()
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, configFolder), 0755))
+
+	// Without a keyring tool the password ends up in the encrypted file
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", t.TempDir())
+	defer os.Setenv(passphraseEnv, os.Getenv(passphraseEnv))
+	os.Setenv(passphraseEnv, "passphrase")
+
+	defer func(s map[string]interface{}) { settings = s }(settings)
+	settings = map[string]interface{}{}
+	for flag, value := range map[string]string{"username": "team1", "password": "secret"} {
+		f := rootCommand.PersistentFlags().Lookup(flag)
+		defer func(previous string) { f.Value.Set(previous); f.Changed = false }(f.Value.String())
+		assert.NoError(t, rootCommand.PersistentFlags().Set(flag, value))
+	}
+
+	assert.NoError(t, addProfile(profileAddCommand, []string{"finals"}))
+	assert.Equal(t, map[string]interface{}{"username": "team1", credentialStoreKey: storeFile}, profiles()["finals"])
+
+	bts, err := ioutil.ReadFile(configFile())
+	assert.NoError(t, err)
+	assert.NotContains(t, string(bts), "secret")
+
+	pass, err := newFileStore().get(credentialKey("finals", "team1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", pass)
+}
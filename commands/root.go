@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"time"
 
 	interactor "github.com/icpctools/api-interactor"
@@ -21,6 +22,14 @@ const (
 	configType   = "yaml"
 )
 
+// environmentVariables maps the settings that can be given through the environment to their variable
+var environmentVariables = map[string]string{
+	"baseurl":  "ICPC_BASEURL",
+	"username": "ICPC_USERNAME",
+	"password": "ICPC_PASSWORD",
+	"contest":  "ICPC_CONTEST",
+}
+
 var (
 	rootCommand = &cobra.Command{
 		Use:   "contest",
@@ -35,14 +44,91 @@ var (
 	languageId string
 	entryPoint string
 
+	outputFormat string
+	profileName  string
+
 	force    bool
 	insecure bool
+	offline  bool
+	wait     bool
+
+	waitTimeout time.Duration
+
+	eventsSince string
+	eventsTypes []string
+
+	scoreboardWatch   time.Duration
+	scoreboardCompact bool
+
+	submitExcludes []string
+	submitMaxFiles int
+	submitMaxSize  int
+
+	testFirst      bool
+	testSamplesDir string
+	testTimeLimit  time.Duration
+
+	problemDownloadDir string
+
+	credentialStoreName string
+
+	clarUnread    bool
+	clarBroadcast bool
+	clarToTeam    string
+
+	submissionsAllJudgements bool
+	submissionsVerdict       string
+	submissionsSince         time.Duration
+	submissionsGetDir        string
+	submissionsResubmit      bool
+
+	statusWatch bool
+
+	notifyPoll    time.Duration
+	notifyHook    string
+	notifyDesktop bool
+
+	dashboardPoll time.Duration
+
+	mockServerDir            string
+	mockServerListen         string
+	mockServerVerdicts       map[string]string
+	mockServerDefaultVerdict string
+	mockServerJudgeDelay     time.Duration
 )
 
+// exitError can be returned by a command to exit with a specific exit code rather than the default of 1.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e exitError) Unwrap() error {
+	return e.err
+}
+
 func Execute() error {
 	return rootCommand.Execute()
 }
 
+// ExitCode returns the code the process should exit with for the given error returned by Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var e exitError
+	if errors.As(err, &e) {
+		return e.code
+	}
+
+	return 1
+}
+
 func init() {
 	// Load root command
 	rootCommand.PersistentFlags().StringVarP(&baseUrl, "baseurl", "b", "", "base URL to use")
@@ -50,34 +136,103 @@ func init() {
 	rootCommand.PersistentFlags().StringVarP(&password, "password", "p", "", "password to communicate with the API")
 	rootCommand.PersistentFlags().StringVarP(&contestId, "contest", "c", "", "contest ID to use")
 	rootCommand.PersistentFlags().BoolVarP(&insecure, "insecure", "i", false, "whether to allow insecure HTTPS connections")
+	rootCommand.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, fmt.Sprintf("output format of listings, one of: %s", strings.Join(outputFormats, ", ")))
+	rootCommand.PersistentFlags().StringVar(&profileName, "profile", "", "profile to use instead of the active one")
+	rootCommand.PersistentFlags().BoolVar(&offline, "offline", false, "use the responses retrieved before instead of connecting to the server")
+	rootCommand.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+
+		if err := loadSettings(); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		return nil
+	}
 
 	// Command specific flags
+	clarCommand.Flags().BoolVar(&clarUnread, "unread", false, "only show clarifications that were not listed before")
+	clarReplyCommand.Flags().BoolVar(&clarBroadcast, "broadcast", false, "send the answer to all teams")
+	clarReplyCommand.Flags().StringVar(&clarToTeam, "to-team", "", "team ID to send the answer to. Leave empty to answer the team that asked")
+	clarReplyCommand.Flags().BoolVarP(&force, "force", "f", false, "whether to force sending the answer (i.e. not ask for confirmation)")
+
 	postClarCommand.Flags().StringVar(&problemId, "problem", "", "problem ID to post a clarification for. Leave empty for general clarification")
+	postClarCommand.Flags().BoolVarP(&force, "force", "f", false, "whether to force posting the clarification (i.e. not ask for confirmation)")
 
 	submitCommand.Flags().StringVar(&problemId, "problem", "", "problem ID to submit for. Leave empty to auto detect from first file")
 	submitCommand.Flags().StringVarP(&languageId, "language", "l", "", "language ID to submit for. Leave empty to auto detect from first file")
 	submitCommand.Flags().StringVarP(&entryPoint, "entry-point", "e", "", "entry point to use. Leave empty if not needed or to auto detect")
 	submitCommand.Flags().BoolVarP(&force, "force", "f", false, "whether to force submission (i.e. not ask for confirmation")
+	submitCommand.Flags().BoolVarP(&wait, "wait", "w", false, "whether to wait for the submission to be judged and exit with a code depending on the verdict")
+	submitCommand.Flags().DurationVar(&waitTimeout, "wait-timeout", 10*time.Minute, "maximum time to wait for a judgement when using --wait. Use 0 to wait indefinitely")
+	submitCommand.Flags().BoolVar(&testFirst, "test-first", false, "whether to test against the problem samples first and refuse to submit when they fail")
+	submitCommand.Flags().StringVar(&testSamplesDir, "samples", "", "directory containing the samples to test against. Leave empty to use samples/<label> or <label>/samples")
+	submitCommand.Flags().DurationVar(&testTimeLimit, "time-limit", 10*time.Second, "time limit per sample when testing")
+	submitCommand.Flags().StringSliceVar(&submitExcludes, "exclude", nil, "glob patterns of files to leave out when submitting a directory, in addition to .contestignore and submit.exclude from the configuration file")
+	submitCommand.Flags().IntVar(&submitMaxFiles, "max-files", 20, "maximum number of files to submit without --force, or submit.max_files from the configuration file. Use 0 for no limit")
+	submitCommand.Flags().IntVar(&submitMaxSize, "max-size", 256, "maximum total size in KiB to submit without --force, or submit.max_size from the configuration file. Use 0 for no limit")
+
+	testCommand.Flags().StringVar(&problemId, "problem", "", "problem ID to test for. Leave empty to auto detect from first file")
+	testCommand.Flags().StringVarP(&languageId, "language", "l", "", "language ID to test with. Leave empty to auto detect from first file")
+	testCommand.Flags().StringVarP(&entryPoint, "entry-point", "e", "", "entry point to use. Leave empty if not needed or to auto detect")
+	testCommand.Flags().StringVar(&testSamplesDir, "samples", "", "directory containing the samples to test against. Leave empty to use samples/<label> or <label>/samples")
+	testCommand.Flags().DurationVar(&testTimeLimit, "time-limit", 10*time.Second, "time limit per sample")
+
+	eventsCommand.Flags().StringVar(&eventsSince, "since", "", "token (or for older servers, id) of the event to resume the feed after")
+	eventsCommand.Flags().StringSliceVarP(&eventsTypes, "type", "t", nil, "event types to show, e.g. submissions,judgements,clarifications. Leave empty for all")
+
+	scoreboardCommand.Flags().DurationVar(&scoreboardWatch, "watch", 0, "refresh the scoreboard every interval until interrupted, e.g. --watch=10s")
+	scoreboardCommand.Flags().Lookup("watch").NoOptDefVal = "30s"
+	scoreboardCommand.Flags().BoolVar(&scoreboardCompact, "compact", false, "only show the labels of solved problems")
+
+	loginCommand.Flags().StringVar(&credentialStoreName, "store", "", fmt.Sprintf("credential store to keep the password in, one of: %s. Leave empty to use the keyring if available, otherwise the encrypted file", strings.Join(credentialStores, ", ")))
+
+	submissionsCommand.Flags().BoolVar(&submissionsAllJudgements, "all-judgements", false, "show every judgement of a submission instead of only the latest")
+	submissionsCommand.Flags().StringVar(&problemId, "problem", "", "only show submissions for this problem")
+	submissionsCommand.Flags().StringVar(&submissionsVerdict, "verdict", "", "only show submissions with this judgement type, e.g. AC or WA")
+	submissionsCommand.Flags().StringVarP(&languageId, "language", "l", "", "only show submissions in this language ID")
+	submissionsCommand.Flags().DurationVar(&submissionsSince, "since", 0, "only show submissions made at or after this contest time, e.g. 1h30m")
+
+	submissionsGetCommand.Flags().StringVarP(&submissionsGetDir, "dir", "d", "", "directory to unpack the files in. Leave empty to use submission-<id>")
+	submissionsGetCommand.Flags().BoolVarP(&force, "force", "f", false, "whether to overwrite existing files")
+	submissionsGetCommand.Flags().BoolVar(&submissionsResubmit, "resubmit", false, "whether to submit the files again")
+
+	statusCommand.Flags().BoolVar(&statusWatch, "watch", false, "keep the clock ticking until interrupted")
+
+	notifyCommand.Flags().DurationVar(&notifyPoll, "poll", 0, "poll the API at this interval instead of following the event feed, e.g. --poll=30s")
+	notifyCommand.Flags().Lookup("poll").NoOptDefVal = "30s"
+	notifyCommand.Flags().StringVar(&notifyHook, "hook", "", "shell command to run for every notification, receiving it as JSON on stdin. Leave empty to use notify.hook from the configuration file")
+	notifyCommand.Flags().BoolVar(&notifyDesktop, "desktop", true, "whether to show desktop notifications when available")
+
+	dashboardCommand.Flags().DurationVar(&dashboardPoll, "poll", 0, "poll the API at this interval instead of following the event feed, e.g. --poll=30s")
+	dashboardCommand.Flags().Lookup("poll").NoOptDefVal = "30s"
+
+	problemDownloadCommand.Flags().StringVarP(&problemDownloadDir, "dir", "d", ".", "directory to create the problem directories in")
+
+	mockServerCommand.Flags().StringVarP(&mockServerDir, "dir", "d", ".", "contest package directory to serve")
+	mockServerCommand.Flags().StringVar(&mockServerListen, "listen", "localhost:8080", "address to listen on")
+	mockServerCommand.Flags().StringToStringVar(&mockServerVerdicts, "verdict", nil, "judgement type to give to submissions per problem id or label, e.g. --verdict A=WA,B=TLE")
+	mockServerCommand.Flags().StringVar(&mockServerDefaultVerdict, "default-verdict", "AC", "judgement type to give to submissions of other problems")
+	mockServerCommand.Flags().DurationVar(&mockServerJudgeDelay, "judge-delay", 2*time.Second, "time it takes to judge a submission or answer a clarification")
 
 	rootCommand.Long = fmt.Sprintf(`%s
 
 Note that if the [-b/--baseurl], [-c/--contest], [-i/--insecure], [-p/--password] and [-u/--username] flags
-are not supplied, they are read from the configuration file (%s)`, rootCommand.Short, configFile())
+are not supplied, they are read from the ICPC_BASEURL, ICPC_CONTEST, ICPC_PASSWORD and ICPC_USERNAME environment
+variables, then from the active profile in the configuration file (%s). The username and password are finally read
+from the entry of the base URL host in ~/.netrc (or the file in $NETRC).`, rootCommand.Short, configFile())
 
-	// Set viper path and file
 	configDir := configdir.LocalConfig(configFolder)
 
 	// Ensure config path exists
 	err := configdir.MakePath(configDir)
 	if err != nil {
-		fmt.Printf("can not create config folder: %s\n", err)
+		fmt.Fprintf(cmdCtx.stderr, "can not create config folder: %s\n", err)
 		os.Exit(1)
 	}
 
-	viper.AddConfigPath(configDir)
-	viper.SetConfigName(configName)
-	viper.SetConfigType(configType)
-
 	// Bind all values
 	allFlags := []string{"baseurl", "username", "password", "contest", "insecure"}
 	for _, flag := range allFlags {
@@ -87,17 +242,41 @@ are not supplied, they are read from the configuration file (%s)`, rootCommand.S
 		}
 	}
 
-	// Read in viper config
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			// TODO replace this with a better method
+	// Environment variables take precedence over the configuration file, but not over flags
+	for flag, env := range environmentVariables {
+		if err := viper.BindEnv(flag, env); err != nil {
 			panic(err)
 		}
 	}
 
+	// Completion of flags and arguments, from the responses retrieved before
+	registerFlagCompletion("contest", completeContests, rootCommand)
+	registerFlagCompletion("output", staticCompletion(outputFormats...), rootCommand)
+	registerFlagCompletion("profile", completeProfiles, rootCommand)
+	registerFlagCompletion("problem", completeProblems, postClarCommand, submitCommand, testCommand, submissionsCommand)
+	registerFlagCompletion("language", completeLanguages, submitCommand, testCommand, submissionsCommand)
+	registerFlagCompletion("verdict", completeJudgementTypes, submissionsCommand)
+	registerFlagCompletion("store", staticCompletion(credentialStores...), loginCommand)
+	setIdCommand.ValidArgsFunction = completeFirstArg(completeContests)
+	submissionsShowCommand.ValidArgsFunction = completeFirstArg(completeSubmissions)
+	submissionsGetCommand.ValidArgsFunction = completeFirstArg(completeSubmissions)
+	clarReplyCommand.ValidArgsFunction = completeFirstArg(completePendingClarifications)
+	problemDownloadCommand.ValidArgsFunction = completeProblemArgs
+	profileUseCommand.ValidArgsFunction = completeFirstArg(completeProfiles)
+	profileRemoveCommand.ValidArgsFunction = completeFirstArg(completeProfiles)
+
 	// Register the subcommands
 	setCommand.AddCommand(setUrlCommand)
 	setCommand.AddCommand(setIdCommand)
+	clarCommand.AddCommand(clarReplyCommand)
+	clarCommand.AddCommand(clarPendingCommand)
+	problemCommand.AddCommand(problemDownloadCommand)
+	submissionsCommand.AddCommand(submissionsShowCommand)
+	submissionsCommand.AddCommand(submissionsGetCommand)
+	profileCommand.AddCommand(profileAddCommand)
+	profileCommand.AddCommand(profileUseCommand)
+	profileCommand.AddCommand(profileListCommand)
+	profileCommand.AddCommand(profileRemoveCommand)
 	rootCommand.AddCommand(contestCommand)
 	rootCommand.AddCommand(clarCommand)
 	rootCommand.AddCommand(postClarCommand)
@@ -108,6 +287,14 @@ are not supplied, they are read from the configuration file (%s)`, rootCommand.S
 	rootCommand.AddCommand(submitCommand)
 	rootCommand.AddCommand(submissionsCommand)
 	rootCommand.AddCommand(scoreboardCommand)
+	rootCommand.AddCommand(eventsCommand)
+	rootCommand.AddCommand(testCommand)
+	rootCommand.AddCommand(profileCommand)
+	rootCommand.AddCommand(notifyCommand)
+	rootCommand.AddCommand(statusCommand)
+	rootCommand.AddCommand(dashboardCommand)
+	rootCommand.AddCommand(mockServerCommand)
+	rootCommand.AddCommand(completionCommand)
 }
 
 // configHelper can be used to register which flags must exist. An error is thrown when a required flag is not present
@@ -138,8 +325,8 @@ func configFile() string {
 	return fmt.Sprintf("%s.%s", filepath.Join(configdir.LocalConfig(configFolder), configName), configType)
 }
 
-// contestApi attempts to load a interactor.ContestApi from the config currently stored in viper.
-func contestApi() (interactor.ContestApi, error) {
+// connectContestApi attempts to load a interactor.ContestApi from the config currently stored in viper.
+func connectContestApi() (interactor.ContestApi, error) {
 	contest := viper.GetString("contest")
 	if contest == "" {
 		api, err := contestsApi()
@@ -152,37 +339,89 @@ func contestApi() (interactor.ContestApi, error) {
 			return nil, fmt.Errorf("could not retrieve contests; %w", err)
 		}
 
+		// Only ask when someone is there to answer, and the output is not consumed by another tool
 		best, err := contestSet(c).bestContest()
-		if err != nil {
+		var ambiguous ambiguousContestError
+		if errors.As(err, &ambiguous) && canPickContest() {
+			if best, err = pickContest(ambiguous); err != nil {
+				return nil, err
+			}
+			contest = best.Id
+		} else if err != nil {
 			return nil, fmt.Errorf("could not pick the best contest; %w", err)
 		} else {
-			fmt.Printf("Automatically connecting to contest: %s\n", best.Name)
+			printBanner("Automatically connecting to contest: %s\n", best.Name)
 			contest = best.Id
 		}
 	}
-	return interactor.ContestInteractor(
-		viper.GetString("baseurl"),
-		viper.GetString("username"),
-		viper.GetString("password"),
-		contest,
-		viper.GetBool("insecure"),
-	)
+
+	user, pass, err := credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	cache := newResponseCache(rawApiFor(user, pass))
+	if offline {
+		return cachedContestApi{ContestApi: offlineContestApi{}, cache: cache, contestId: contest}, nil
+	}
+
+	// Validate the contest through the cache, which suggests --offline when the server cannot be reached
+	if _, err := (cachedContestsApi{cache: cache}).ContestById(contest); err != nil {
+		return nil, fmt.Errorf("could not find contest; %w", err)
+	}
+
+	api := &lazyContestApi{connect: func() (interactor.ContestApi, error) {
+		return interactor.ContestInteractor(
+			viper.GetString("baseurl"),
+			user,
+			pass,
+			contest,
+			viper.GetBool("insecure"),
+		)
+	}}
+
+	return cachedContestApi{ContestApi: api, cache: cache, contestId: contest}, nil
 }
 
-// contestsApi attempts to load a interactor.ContestsApi from the config currently stored in viper.
-func contestsApi() (interactor.ContestsApi, error) {
-	return interactor.ContestsInteractor(
+// connectContestsApi attempts to load a interactor.ContestsApi from the config currently stored in viper.
+func connectContestsApi() (interactor.ContestsApi, error) {
+	user, pass, err := credentials()
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := interactor.ContestsInteractor(
 		viper.GetString("baseurl"),
-		viper.GetString("username"),
-		viper.GetString("password"),
+		user,
+		pass,
 		viper.GetBool("insecure"),
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	return cachedContestsApi{ContestsApi: api, cache: newResponseCache(rawApiFor(user, pass))}, nil
 }
 
 type (
 	contestSet []interactor.Contest
+
+	// ambiguousContestError is returned when there is no obvious contest to pick from the candidates.
+	ambiguousContestError struct {
+		reason     string
+		candidates []interactor.Contest
+	}
 )
 
+func (e ambiguousContestError) Error() string {
+	var ids []string
+	for _, c := range e.candidates {
+		ids = append(ids, c.Id)
+	}
+
+	return fmt.Sprintf("%s, use --contest or set id with one of: %s", e.reason, strings.Join(ids, ", "))
+}
+
 func (c contestSet) bestContest() (interactor.Contest, error) {
 	var best interactor.Contest
 
@@ -198,7 +437,7 @@ func (c contestSet) bestContest() (interactor.Contest, error) {
 	// if there is only one contest running, pick it
 	var count int
 	var unscheduled int
-	now := time.Now()
+	now := cmdCtx.now()
 	for _, contest := range c {
 		if contest.StartTime == (interactor.ApiTime{}) {
 			unscheduled++
@@ -212,10 +451,16 @@ func (c contestSet) bestContest() (interactor.Contest, error) {
 	if count == 1 {
 		return best, nil
 	} else if count >= 2 {
-		return interactor.Contest{}, errors.New("more than one contest is currently running")
+		var running []interactor.Contest
+		for _, contest := range c {
+			if contest.StartTime.Time().Before(now) && contest.StartTime.Time().Add(time.Duration(contest.Duration)).After(now) {
+				running = append(running, contest)
+			}
+		}
+		return interactor.Contest{}, ambiguousContestError{"more than one contest is currently running", running}
 	}
 	if unscheduled == len(c) {
-		return interactor.Contest{}, errors.New("there are no scheduled contests")
+		return interactor.Contest{}, ambiguousContestError{"there are no scheduled contests", c}
 	}
 
 	// if there is only one contest today, pick it
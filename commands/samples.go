@@ -0,0 +1,463 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	sampleAccepted     = "AC"
+	sampleWrongAnswer  = "WA"
+	sampleRunError     = "RTE"
+	sampleTimeLimit    = "TLE"
+	sampleCompileError = "CE"
+
+	// maxDiffLines is the maximum number of differing lines shown for a wrong answer
+	maxDiffLines = 10
+)
+
+var testCommand = &cobra.Command{
+	Use:   "test [file1] <file2> <file3> ...",
+	Short: "Test one or more files against the problem samples",
+	Long: `Test one or more files against the problem samples
+
+The problem and language are detected the same way as for submit. The files are compiled and run against every
+sample input (*.in) in the samples/<label>/ directory, or the <label>/samples/ directory created by problem download,
+comparing the output to the sample answer (*.ans or *.out).
+
+The commands used to compile and run a language can be overridden in the configuration file, e.g.:
+
+  test:
+    commands:
+      cpp:
+        compile: g++ -O2 -std=gnu++17 -o {binary} {files}
+        run: {binary}
+
+Available placeholders are {files} (all source files of the language), {main} (the first file), {dir} (the build directory), {binary} (an
+executable in the build directory) and {entry} (the entry point). Quote arguments that contain spaces, e.g.
+-cp "{dir}/my classes".`,
+	Args:    cobra.MinimumNArgs(1),
+	RunE:    runSampleTests,
+	PreRunE: configHelper("baseurl"),
+}
+
+type (
+	// languageCommands contains the commands to compile and run a language. Compile may be empty for interpreted
+	// languages.
+	languageCommands struct {
+		Compile string `mapstructure:"compile"`
+		Run     string `mapstructure:"run"`
+	}
+
+	sampleCase struct {
+		name   string
+		input  string
+		answer string
+	}
+
+	sampleResult struct {
+		sampleCase
+		verdict string
+		time    time.Duration
+		details string
+	}
+)
+
+// defaultLanguageCommands is keyed by language id or extension.
+var defaultLanguageCommands = map[string]languageCommands{
+	"c":       {Compile: "gcc -O2 -o {binary} {files} -lm", Run: "{binary}"},
+	"cpp":     {Compile: "g++ -O2 -std=gnu++17 -o {binary} {files}", Run: "{binary}"},
+	"java":    {Compile: "javac -d {dir} {files}", Run: "java -cp {dir} {entry}"},
+	"kotlin":  {Compile: "kotlinc -d {dir} {files}", Run: "kotlin -cp {dir} {entry}"},
+	"python":  {Run: "python {main}"},
+	"python2": {Run: "python2 {main}"},
+	"python3": {Run: "python3 {main}"},
+	"py":      {Run: "python3 {main}"},
+}
+
+func runSampleTests(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	api, err := contestApi()
+	if err != nil {
+		return fmt.Errorf("could not connect to the server; %w", err)
+	}
+
+	problems, err := api.Problems()
+	if err != nil {
+		return fmt.Errorf("could not get problems; %w", err)
+	}
+
+	languages, err := api.Languages()
+	if err != nil {
+		return fmt.Errorf("could not get languages; %w", err)
+	}
+
+	problem, language, err := detectProblemAndLanguage(args, problems, languages)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmdCtx.stdout, "Testing problem %s using %s\n", problem.Label, language.Name)
+	results, err := testSamples(problem, language, args, entryPoint)
+	if err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		return fmt.Errorf("no samples found for problem %s in %s", problem.Label, strings.Join(sampleDirs(problem), " or "))
+	}
+
+	if failed := printSampleResults(results); failed > 0 {
+		return fmt.Errorf("%d of %d samples failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// testSamples compiles the files and runs them against all samples of the problem, using the entry point or else the
+// one detected. No results are returned if there are no samples. A compile error is returned as a single result with
+// the CE verdict.
+func testSamples(problem interactor.Problem, language interactor.Language, files []string, entry string) ([]sampleResult, error) {
+	cases, err := findSamples(problem)
+	if err != nil || len(cases) == 0 {
+		return nil, err
+	}
+
+	commands, found := lookupLanguageCommands(language)
+	if !found {
+		return nil, fmt.Errorf("no commands known to compile and run %s, add them to the configuration file", language.Name)
+	}
+
+	dir, err := ioutil.TempDir("", "contest-test-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create build directory; %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if entry == "" {
+		entry = detectEntryPoint(language, files)
+	}
+
+	var absFiles, sourceFiles []string
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		absFiles = append(absFiles, abs)
+
+		// Other files of a directory submission, such as a README or test input, are not passed to the compiler
+		if _, ok := (languageSet{language}).byExtension(strings.ToLower(strings.TrimPrefix(filepath.Ext(file), "."))); ok {
+			sourceFiles = append(sourceFiles, abs)
+		}
+	}
+	if len(sourceFiles) == 0 {
+		sourceFiles = absFiles
+	}
+
+	binary := filepath.Join(dir, "solution")
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	placeholders := map[string][]string{
+		"{files}":  sourceFiles,
+		"{main}":   {absFiles[0]},
+		"{dir}":    {dir},
+		"{binary}": {binary},
+		"{entry}":  {entry},
+	}
+
+	if commands.Compile != "" {
+		compile := expandCommand(commands.Compile, placeholders)
+		c := exec.Command(compile[0], compile[1:]...)
+		c.Dir = dir
+		if out, err := c.CombinedOutput(); err != nil {
+			return []sampleResult{{
+				sampleCase: sampleCase{name: "compile"},
+				verdict:    sampleCompileError,
+				details:    strings.TrimSpace(fmt.Sprintf("%v\n%s", err, out)),
+			}}, nil
+		}
+	}
+
+	run := expandCommand(commands.Run, placeholders)
+	var results []sampleResult
+	for _, c := range cases {
+		results = append(results, runSample(run, dir, c))
+	}
+
+	return results, nil
+}
+
+// runSample runs a single sample case and judges its output.
+func runSample(run []string, dir string, c sampleCase) sampleResult {
+	result := sampleResult{sampleCase: c}
+
+	input, err := os.Open(c.input)
+	if err != nil {
+		result.verdict = sampleRunError
+		result.details = err.Error()
+		return result
+	}
+	defer input.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeLimit)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, run[0], run[1:]...)
+	cmd.Dir = dir
+	cmd.Stdin = input
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	result.time = time.Since(start)
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.verdict = sampleTimeLimit
+		result.details = fmt.Sprintf("killed after %v", testTimeLimit)
+		return result
+	}
+
+	if err != nil {
+		result.verdict = sampleRunError
+		result.details = strings.TrimSpace(fmt.Sprintf("%v\n%s", err, stderr.String()))
+		return result
+	}
+
+	answer, err := ioutil.ReadFile(c.answer)
+	if err != nil {
+		result.verdict = sampleRunError
+		result.details = fmt.Sprintf("could not read answer; %v", err)
+		return result
+	}
+
+	if outputMatches(answer, stdout.Bytes()) {
+		result.verdict = sampleAccepted
+	} else {
+		result.verdict = sampleWrongAnswer
+		result.details = outputDiff(string(answer), stdout.String())
+	}
+
+	return result
+}
+
+// printSampleResults prints a table with the results, followed by the details of failing cases. The number of
+// failing cases is returned.
+func printSampleResults(results []sampleResult) int {
+	var failed int
+	var table = Table{}
+	table.Header = []string{"Case", "Verdict", "Time"}
+	table.Align = []int{ALIGN_LEFT, ALIGN_LEFT, ALIGN_RIGHT}
+	for _, r := range results {
+		if r.verdict != sampleAccepted {
+			failed++
+		}
+
+		var t string
+		if r.time > 0 {
+			t = fmt.Sprintf("%.2fs", r.time.Seconds())
+		}
+		table.appendRow([]string{r.name, r.verdict, t})
+	}
+	table.print()
+
+	for _, r := range results {
+		if r.details != "" {
+			fmt.Fprintf(cmdCtx.stdout, "\n%s (%s):\n%s\n", r.name, r.verdict, r.details)
+		}
+	}
+
+	return failed
+}
+
+// sampleDirs returns the directories that are searched for samples of the problem.
+func sampleDirs(problem interactor.Problem) []string {
+	if testSamplesDir != "" {
+		return []string{testSamplesDir}
+	}
+
+	return []string{filepath.Join("samples", problem.Label), filepath.Join(problem.Label, "samples")}
+}
+
+// findSamples returns all sample cases of the problem, i.e. all input files that have a matching answer file.
+func findSamples(problem interactor.Problem) ([]sampleCase, error) {
+	for _, dir := range sampleDirs(problem) {
+		inputs, err := filepath.Glob(filepath.Join(dir, "*.in"))
+		if err != nil {
+			return nil, err
+		}
+
+		var cases []sampleCase
+		for _, input := range inputs {
+			base := strings.TrimSuffix(input, ".in")
+			for _, extension := range []string{".ans", ".out"} {
+				if _, err := os.Stat(base + extension); err == nil {
+					cases = append(cases, sampleCase{name: filepath.Base(base), input: input, answer: base + extension})
+					break
+				}
+			}
+		}
+
+		if len(cases) > 0 {
+			sort.Slice(cases, func(i, j int) bool {
+				return cases[i].name < cases[j].name
+			})
+			return cases, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// lookupLanguageCommands finds the commands for a language, first in the configuration file and then in the defaults.
+// Both are looked up by language id and then by the extensions of the language.
+func lookupLanguageCommands(language interactor.Language) (languageCommands, bool) {
+	configured := map[string]languageCommands{}
+	if err := viper.UnmarshalKey("test.commands", &configured); err != nil {
+		fmt.Fprintf(cmdCtx.stderr, "ignoring invalid test commands in configuration file; %v\n", err)
+	}
+
+	keys := append([]string{language.Id}, language.Extensions...)
+	for _, commands := range []map[string]languageCommands{configured, defaultLanguageCommands} {
+		for _, key := range keys {
+			if c, ok := commands[strings.ToLower(key)]; ok && c.Run != "" {
+				return c, true
+			}
+		}
+	}
+
+	return languageCommands{}, false
+}
+
+// commandField is an argument of a command, and whether it was quoted.
+type commandField struct {
+	text   string
+	quoted bool
+}
+
+// commandFields splits a command into its arguments at whitespace. Like in a shell, single or double quotes keep
+// whitespace in an argument, and are removed.
+func commandFields(command string) []commandField {
+	var fields []commandField
+	var field commandField
+	var inField bool
+	var quote rune
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			field.text += string(r)
+		case r == '\'' || r == '"':
+			quote, inField, field.quoted = r, true, true
+		case unicode.IsSpace(r):
+			if inField {
+				fields = append(fields, field)
+			}
+			field, inField = commandField{}, false
+		default:
+			field.text += string(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// expandCommand splits a command into its arguments and replaces the placeholders in it. An unquoted argument that is
+// only a placeholder becomes one argument per value, e.g. per file. A placeholder in a quoted argument or in a longer
+// one, like "{dir}" or {dir}/classes, stays a single argument, with multiple values separated by spaces.
+func expandCommand(command string, placeholders map[string][]string) []string {
+	var args []string
+	for _, field := range commandFields(command) {
+		if values, ok := placeholders[field.text]; ok && !field.quoted {
+			args = append(args, values...)
+			continue
+		}
+
+		text := field.text
+		for placeholder, values := range placeholders {
+			text = strings.ReplaceAll(text, placeholder, strings.Join(values, " "))
+		}
+		args = append(args, text)
+	}
+
+	return args
+}
+
+// outputMatches compares the output to the answer token by token, ignoring differences in whitespace.
+func outputMatches(answer, output []byte) bool {
+	expected := strings.Fields(string(answer))
+	got := strings.Fields(string(output))
+	if len(expected) != len(got) {
+		return false
+	}
+
+	for i := range expected {
+		if expected[i] != got[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// outputDiff shows the lines that differ between the answer and the output.
+func outputDiff(answer, output string) string {
+	expected := strings.Split(strings.TrimRight(answer, "\n"), "\n")
+	got := strings.Split(strings.TrimRight(output, "\n"), "\n")
+
+	var diff []string
+	var shown int
+	for i := 0; i < len(expected) || i < len(got); i++ {
+		var e, g string
+		if i < len(expected) {
+			e = expected[i]
+		}
+		if i < len(got) {
+			g = got[i]
+		}
+
+		if strings.Join(strings.Fields(e), " ") == strings.Join(strings.Fields(g), " ") {
+			continue
+		}
+
+		if shown == maxDiffLines {
+			diff = append(diff, "  ...")
+			break
+		}
+		shown++
+
+		if i < len(expected) {
+			diff = append(diff, fmt.Sprintf("  line %d expected: %s", i+1, e))
+		}
+		if i < len(got) {
+			diff = append(diff, fmt.Sprintf("  line %d got:      %s", i+1, g))
+		} else {
+			diff = append(diff, fmt.Sprintf("  line %d missing", i+1))
+		}
+	}
+
+	return strings.Join(diff, "\n")
+}
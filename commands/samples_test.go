@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandCommand(t *testing.T) {
+	placeholders := map[string][]string{
+		"{files}":  {"/src/a.cpp", "/src/b.cpp"},
+		"{binary}": {"/tmp/solution"},
+		"{dir}":    {"/tmp/my dir"},
+	}
+
+	assert.EqualValues(t,
+		[]string{"g++", "-O2", "-o", "/tmp/solution", "/src/a.cpp", "/src/b.cpp"},
+		expandCommand("g++ -O2 -o {binary} {files}", placeholders))
+	assert.EqualValues(t,
+		[]string{"java", "-Xss64m", "-cp", "/tmp/my dir/classes"},
+		expandCommand("java -Xss64m -cp {dir}/classes", placeholders))
+	assert.EqualValues(t,
+		[]string{"sh", "-c", "cat /src/a.cpp /src/b.cpp > '/tmp/my dir/all'", "/tmp/my dir", ""},
+		expandCommand(`sh -c "cat {files} > '{dir}/all'" '{dir}' ""`, placeholders))
+}
+
+func TestOutputMatches(t *testing.T) {
+	assert.True(t, outputMatches([]byte("1 2\n3\n"), []byte("1  2 3")))
+	assert.False(t, outputMatches([]byte("1 2\n3\n"), []byte("1 2\n4\n")))
+	assert.False(t, outputMatches([]byte("1 2\n3\n"), []byte("1 2\n")))
+}
+
+func TestOutputDiff(t *testing.T) {
+	assert.EqualValues(t, "  line 2 expected: 3\n  line 2 got:      4", outputDiff("1 2\n3\n", "1  2\n4\n"))
+	assert.EqualValues(t, "  line 2 expected: 3\n  line 2 missing", outputDiff("1 2\n3\n", "1 2\n"))
+}
+
+func TestFindSamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "samples")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"2.in", "2.ans", "1.in", "1.out", "3.in"} {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	defer func(d string) { testSamplesDir = d }(testSamplesDir)
+	testSamplesDir = dir
+
+	cases, err := findSamples(interactor.Problem{Label: "A"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []sampleCase{
+		{name: "1", input: filepath.Join(dir, "1.in"), answer: filepath.Join(dir, "1.out")},
+		{name: "2", input: filepath.Join(dir, "2.in"), answer: filepath.Join(dir, "2.ans")},
+	}, cases)
+}
+
+func TestTestSamples(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/Main.java":   "class Main {}",
+		"src/Util.java":   "class Util {}",
+		"src/README.md":   "notes",
+		"samples/A/1.in":  "",
+		"samples/A/1.ans": "",
+	})
+
+	defer func(d string) { testSamplesDir = d }(testSamplesDir)
+	testSamplesDir = filepath.Join(dir, "samples", "A")
+	setViper(t, "test.commands", map[string]interface{}{
+		"java": map[string]interface{}{"run": "echo {entry} {files}"},
+	})
+
+	var files []string
+	for _, name := range []string{"Main.java", "Util.java", "README.md"} {
+		files = append(files, filepath.Join(dir, "src", name))
+	}
+
+	// The given entry point is used, and only the source files are passed
+	results, err := testSamples(interactor.Problem{Label: "A"}, interactor.Language{Id: "java", Extensions: []string{"java"}}, files, "solution.Main")
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, sampleWrongAnswer, results[0].verdict)
+	assert.Contains(t, results[0].details, "solution.Main "+files[0]+" "+files[1])
+	assert.NotContains(t, results[0].details, "README")
+}
@@ -1,21 +1,56 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
 
+	interactor "github.com/icpctools/api-interactor"
 	"github.com/spf13/cobra"
 )
 
+const (
+	colorReset  = "\033[0m"
+	colorGreen  = "\033[32m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+
+	clearScreen = "\033[H\033[2J"
+
+	scoreboardLegend = "n/t: solved after n attempts at minute t, *: first to solve, n: n rejected attempts, +p?: p pending"
+)
+
 var scoreboardCommand = &cobra.Command{
-	Use:     "scoreboard",
-	Short:   "Show the contest scoreboard",
+	Use:   "scoreboard",
+	Short: "Show the contest scoreboard",
+	Long: `Show the contest scoreboard
+
+When --watch is given, the scoreboard is refreshed in place every interval (e.g. --watch=10s). Rows of teams that
+moved up or down, or that solved a new problem since the previous refresh, are marked in the Change column.
+
+Problem cells show the attempts and solve time, e.g. 3/87 (solved after 3 attempts at minute 87), 1/12* (first to
+solve the problem), 2 (2 rejected attempts) or 2+1? (2 rejected attempts and 1 pending). Use --compact to only show the
+labels of solved problems instead.`,
 	Args:    cobra.NoArgs,
 	RunE:    scoreboard,
 	PreRunE: configHelper("baseurl"),
 }
 
+// scoreboardState is what is remembered of a team between two refreshes of a watched scoreboard.
+type scoreboardState struct {
+	rank   int
+	solved map[string]bool
+}
+
 func scoreboard(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
+	if scoreboardWatch > 0 && machineOutput() {
+		return fmt.Errorf("--watch is only supported with the %s output format", outputTable)
+	}
+
 	api, err := contestApi()
 	if err != nil {
 		return fmt.Errorf("could not connect to the server; %w", err)
@@ -31,21 +66,79 @@ func scoreboard(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not retrieve teams; %w", err)
 	}
 
+	if scoreboardWatch > 0 {
+		return watchScoreboard(api, problems, t)
+	}
+
 	sc, err := api.Scoreboard()
 	if err != nil {
 		return fmt.Errorf("could not retrieve scoreboard; %w", err)
 	}
 
-	fmt.Printf("\nContest Scoreboard\n")
+	printBanner("\nContest Scoreboard\n")
+	table, _ := scoreboardTable(problems, t, sc, nil)
+	table.print()
+	if !scoreboardCompact {
+		printBanner("\n  %s\n", scoreboardLegend)
+	}
+
+	return nil
+}
+
+// watchScoreboard redraws the scoreboard every interval until interrupted. Problems and teams are only fetched once.
+func watchScoreboard(api interactor.ContestApi, problems []interactor.Problem, teams []interactor.Team) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(scoreboardWatch)
+	defer ticker.Stop()
+
+	// Start with an empty state, so the change column is shown from the first refresh on
+	previous := map[string]scoreboardState{}
+	for {
+		sc, err := api.Scoreboard()
+		if err != nil {
+			fmt.Fprintf(cmdCtx.stderr, "could not retrieve scoreboard; %v\n", err)
+		} else {
+			var table Table
+			table, previous = scoreboardTable(problems, teams, sc, previous)
+
+			fmt.Fprint(cmdCtx.stdout, clearScreen)
+			fmt.Fprintf(cmdCtx.stdout, "Contest Scoreboard (updated %s, refreshing every %v, Ctrl+C to stop)\n", cmdCtx.now().Format("15:04:05"), scoreboardWatch)
+			table.print()
+			if !scoreboardCompact {
+				fmt.Fprintf(cmdCtx.stdout, "\n  %s\n", scoreboardLegend)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// scoreboardTable builds the table for a scoreboard. When previous is not nil, a column is added marking the changes
+// compared to it. The state to compare the next refresh against is returned.
+func scoreboardTable(problems []interactor.Problem, t []interactor.Team, sc interactor.Scoreboard, previous map[string]scoreboardState) (Table, map[string]scoreboardState) {
 	var table = Table{}
-	table.Header = []string{"Rank", "Team"}
-	table.Align = []int{ALIGN_RIGHT, ALIGN_LEFT}
+	if previous != nil {
+		table.Header = []string{"Change"}
+		table.Align = []int{ALIGN_LEFT}
+	}
+
+	table.Header = append(table.Header, "Rank", "Team")
+	table.Align = append(table.Align, ALIGN_RIGHT, ALIGN_LEFT)
 	for _, p := range problems {
 		table.Header = append(table.Header, p.Label)
 		table.Align = append(table.Align, ALIGN_RIGHT)
 	}
 	table.Header = append(table.Header, "Solved", "Time")
 	table.Align = append(table.Align, ALIGN_RIGHT, ALIGN_RIGHT)
+
+	firstSolved := firstSolveTimes(sc)
+	current := make(map[string]scoreboardState, len(sc.Rows))
 	for _, r := range sc.Rows {
 		team, _ := teamSet(t).byId(string(r.TeamId))
 		var name = team.Name
@@ -54,25 +147,101 @@ func scoreboard(cmd *cobra.Command, args []string) error {
 		}
 		var row = []string{fmt.Sprintf("%d", r.Rank), team.Id + ": " + name}
 
+		state := scoreboardState{rank: r.Rank, solved: map[string]bool{}}
 		for _, p := range problems {
-			var solved bool
-			for _, rp := range r.Problems {
-				if rp.Solved && string(rp.ProblemId) == p.Id {
-					solved = true
+			var rp interactor.ScoreProblem
+			for _, candidate := range r.Problems {
+				if string(candidate.ProblemId) == p.Id {
+					rp = candidate
 				}
 			}
-			if solved {
-				row = append(row, p.Label)
+			if rp.Solved {
+				state.solved[p.Id] = true
+			}
+
+			if scoreboardCompact {
+				if rp.Solved {
+					row = append(row, p.Label)
+				} else {
+					row = append(row, "")
+				}
 			} else {
-				row = append(row, "")
+				first, hasFirst := firstSolved[p.Id]
+				row = append(row, scoreboardCell(rp, rp.Solved && hasFirst && rp.Time == first))
 			}
 		}
+		current[string(r.TeamId)] = state
 
 		row = append(row, fmt.Sprintf("%d", r.Score.NumSolved), fmt.Sprintf("%v", r.Score.TotalTime))
+		if previous != nil {
+			row = append([]string{scoreboardChange(problems, previous[string(r.TeamId)], state)}, row...)
+		}
 		table.appendRow(row)
 	}
 
-	table.print()
+	return table, current
+}
 
-	return nil
+// firstSolveTimes returns per problem the earliest time it was solved. The scoreboard of the interactor does not
+// contain the first_to_solve flag, so teams solving a problem at that time are considered the first to solve it.
+func firstSolveTimes(sc interactor.Scoreboard) map[string]int {
+	first := map[string]int{}
+	for _, r := range sc.Rows {
+		for _, rp := range r.Problems {
+			if !rp.Solved {
+				continue
+			}
+
+			if t, ok := first[string(rp.ProblemId)]; !ok || rp.Time < t {
+				first[string(rp.ProblemId)] = rp.Time
+			}
+		}
+	}
+
+	return first
+}
+
+// scoreboardCell formats the result of a team for a single problem, e.g. "3/87", "2+1?" or "1/12*".
+func scoreboardCell(rp interactor.ScoreProblem, firstToSolve bool) string {
+	if rp.Solved {
+		cell := fmt.Sprintf("%d/%d", rp.NumJudged, rp.Time)
+		if firstToSolve {
+			cell += "*"
+		}
+		return cell
+	}
+
+	if rp.NumJudged == 0 && rp.NumPending == 0 {
+		return ""
+	}
+
+	cell := fmt.Sprintf("%d", rp.NumJudged)
+	if rp.NumPending > 0 {
+		cell += fmt.Sprintf("+%d?", rp.NumPending)
+	}
+
+	return cell
+}
+
+// scoreboardChange describes how a team changed between two refreshes, e.g. "▲2 +C".
+func scoreboardChange(problems []interactor.Problem, before, after scoreboardState) string {
+	// Teams that were not on the previous scoreboard have nothing to compare against
+	if before.solved == nil {
+		return ""
+	}
+
+	var changes []string
+	if after.rank < before.rank {
+		changes = append(changes, fmt.Sprintf("%s▲%d%s", colorGreen, before.rank-after.rank, colorReset))
+	} else if after.rank > before.rank {
+		changes = append(changes, fmt.Sprintf("%s▼%d%s", colorRed, after.rank-before.rank, colorReset))
+	}
+
+	for _, p := range problems {
+		if after.solved[p.Id] && !before.solved[p.Id] {
+			changes = append(changes, fmt.Sprintf("%s+%s%s", colorYellow, p.Label, colorReset))
+		}
+	}
+
+	return strings.Join(changes, " ")
 }
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"testing"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreboardCell(t *testing.T) {
+	testcases := []struct {
+		name         string
+		problem      interactor.ScoreProblem
+		firstToSolve bool
+		expectedCell string
+	}{
+		{"untouched", interactor.ScoreProblem{}, false, ""},
+		{"solved", interactor.ScoreProblem{NumJudged: 3, Solved: true, Time: 87}, false, "3/87"},
+		{"first to solve", interactor.ScoreProblem{NumJudged: 1, Solved: true, Time: 12}, true, "1/12*"},
+		{"rejected", interactor.ScoreProblem{NumJudged: 2}, false, "2"},
+		{"pending", interactor.ScoreProblem{NumJudged: 2, NumPending: 1}, false, "2+1?"},
+		{"only pending", interactor.ScoreProblem{NumPending: 1}, false, "0+1?"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.EqualValues(t, tc.expectedCell, scoreboardCell(tc.problem, tc.firstToSolve))
+		})
+	}
+}
+
+func TestFirstSolveTimes(t *testing.T) {
+	sc := interactor.Scoreboard{Rows: []interactor.Row{
+		{TeamId: "1", Problems: []interactor.ScoreProblem{{ProblemId: "a", Solved: true, Time: 40}, {ProblemId: "b", Solved: true, Time: 20}}},
+		{TeamId: "2", Problems: []interactor.ScoreProblem{{ProblemId: "a", Solved: true, Time: 30}, {ProblemId: "b", NumJudged: 4, Time: 0}}},
+	}}
+
+	assert.EqualValues(t, map[string]int{"a": 30, "b": 20}, firstSolveTimes(sc))
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 var setCommand = &cobra.Command{
@@ -31,22 +30,25 @@ var setIdCommand = &cobra.Command{
 
 func setUrl(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
-	viper.Set("baseurl", args[0])
-	if err := viper.WriteConfigAs(configFile()); err != nil {
+	if err := updateProfile(map[string]interface{}{"baseurl": args[0]}); err != nil {
 		return err
 	}
 
-	fmt.Printf("Base URL set to %s.\n", args[0])
+	fmt.Fprintf(cmdCtx.stdout, "Base URL of profile %s set to %s.\n", activeProfile, args[0])
 	return nil
 }
 
 func setId(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
-	viper.Set("contest", args[0])
-	if err := viper.WriteConfigAs(configFile()); err != nil {
+	return saveContestId(args[0])
+}
+
+// saveContestId stores the contest ID in the active profile.
+func saveContestId(id string) error {
+	if err := updateProfile(map[string]interface{}{"contest": id}); err != nil {
 		return err
 	}
 
-	fmt.Printf("Contest ID set to %s.\n", args[0])
+	fmt.Fprintf(cmdCtx.stdout, "Contest ID of profile %s set to %s.\n", activeProfile, id)
 	return nil
 }
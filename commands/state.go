@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kirsle/configdir"
+)
+
+// stateFileName is the file in the configuration folder keeping track of what the user has seen
+const stateFileName = "state.json"
+
+// contestState is the local state kept per contest.
+type contestState struct {
+	SeenClarifications []string `json:"seen_clarifications,omitempty"`
+}
+
+// stateFile returns the location of the local state.
+func stateFile() string {
+	return filepath.Join(configdir.LocalConfig(configFolder), stateFileName)
+}
+
+// stateKey identifies a contest in the local state.
+func stateKey(baseUrl, contestId string) string {
+	return fmt.Sprintf("%s/contests/%s", strings.TrimRight(baseUrl, "/"), contestId)
+}
+
+// readState returns the local state of all contests, keyed by stateKey.
+func readState() (map[string]contestState, error) {
+	state := map[string]contestState{}
+	bts, err := ioutil.ReadFile(stateFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read state; %w", err)
+	}
+
+	if err := json.Unmarshal(bts, &state); err != nil {
+		return nil, fmt.Errorf("could not parse state file %s; %w", stateFile(), err)
+	}
+
+	return state, nil
+}
+
+// loadContestState returns the local state of a single contest.
+func loadContestState(key string) (contestState, error) {
+	state, err := readState()
+	if err != nil {
+		return contestState{}, err
+	}
+
+	return state[key], nil
+}
+
+// saveContestState replaces the local state of a single contest.
+func saveContestState(key string, cs contestState) error {
+	state, err := readState()
+	if err != nil {
+		return err
+	}
+
+	state[key] = cs
+	bts, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(stateFile(), bts, 0600); err != nil {
+		return fmt.Errorf("could not write state; %w", err)
+	}
+
+	return nil
+}
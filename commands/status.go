@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// statusRefreshInterval is how often the contest and its state are retrieved again when watching the clock
+	statusRefreshInterval = 30 * time.Second
+
+	stateNotScheduled = "not scheduled"
+	statePaused       = "countdown paused"
+	stateScheduled    = "scheduled"
+	stateStarted      = "started"
+	stateFrozen       = "frozen"
+	stateEnded        = "ended"
+	stateFinalized    = "finalized"
+)
+
+var statusCommand = &cobra.Command{
+	Use:   "status",
+	Short: "Show the contest clock and state",
+	Long: `Show the contest clock and state
+
+Shows the time until the start, the elapsed and remaining contest time, the time until the scoreboard freezes and the
+state of the contest. When --watch is given, the clock keeps ticking until interrupted.`,
+	Args:    cobra.NoArgs,
+	RunE:    status,
+	PreRunE: configHelper("baseurl"),
+}
+
+func status(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if statusWatch && machineOutput() {
+		return fmt.Errorf("--watch is only supported with the %s output format", outputTable)
+	}
+
+	api, err := contestApi()
+	if err != nil {
+		return fmt.Errorf("could not connect to the server; %w", err)
+	}
+
+	contest, state, err := contestAndState(api)
+	if err != nil {
+		return err
+	}
+
+	if !statusWatch {
+		printBanner("\nContest status:\n")
+		statusTable(contest, state, cmdCtx.now()).print()
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	refreshed := cmdCtx.now()
+	for {
+		now := cmdCtx.now()
+		if now.Sub(refreshed) >= statusRefreshInterval {
+			refreshed = now
+			if c, s, err := contestAndState(api); err == nil {
+				contest, state = c, s
+			}
+		}
+
+		fmt.Fprint(cmdCtx.stdout, clearScreen)
+		fmt.Fprintf(cmdCtx.stdout, "Contest status (%s, Ctrl+C to stop)\n", now.Format("15:04:05"))
+		statusTable(contest, state, now).print()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// contestAndState retrieves the contest and its state. Servers that do not provide the state return an empty one.
+func contestAndState(api interactor.ContestApi) (interactor.Contest, interactor.State, error) {
+	contest, err := api.Contest()
+	if err != nil {
+		return contest, interactor.State{}, fmt.Errorf("could not get contest; %w", err)
+	}
+
+	state, err := api.State()
+	if err != nil {
+		state = interactor.State{}
+	}
+
+	return contest, state, nil
+}
+
+// statusTable builds a single row table with the clock and state of the contest at the given time.
+func statusTable(c interactor.Contest, st interactor.State, now time.Time) Table {
+	var name = c.FormalName
+	if name == "" {
+		name = c.Name
+	}
+
+	var start, elapsed, remaining, freeze string
+	scheduled := c.StartTime != (interactor.ApiTime{})
+	startTime := c.StartTime.Time()
+	duration := c.Duration.Duration()
+	freezeDuration := c.ScoreboardFreezeDuration.Duration()
+
+	switch {
+	case !scheduled && c.CountdownTime != 0:
+		start = fmt.Sprintf("in %s (paused)", formatClock(c.CountdownTime.Duration()))
+	case !scheduled:
+		start = "not scheduled"
+	case now.Before(startTime):
+		start = "in " + formatClock(startTime.Sub(now))
+	default:
+		start = startTime.Local().Format("15:04:05")
+
+		passed := now.Sub(startTime)
+		if passed > duration {
+			passed = duration
+		}
+		elapsed = formatClock(passed)
+		remaining = formatClock(duration - passed)
+	}
+
+	if freezeDuration > 0 && scheduled {
+		freezeTime := startTime.Add(duration - freezeDuration)
+		if now.Before(freezeTime) {
+			freeze = "in " + formatClock(freezeTime.Sub(now))
+		} else if now.Before(startTime.Add(duration)) {
+			freeze = "frozen"
+		}
+	}
+
+	var table = Table{}
+	table.Header = []string{"Contest", "State", "Start", "Elapsed", "Remaining", "Freeze"}
+	table.Align = []int{ALIGN_LEFT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_RIGHT, ALIGN_RIGHT, ALIGN_LEFT}
+	table.appendRow([]string{name, contestPhase(c, st, now), start, elapsed, remaining, freeze})
+	return table
+}
+
+// contestPhase describes the state of the contest. The state reported by the server is used when available, otherwise
+// it is derived from the contest times.
+func contestPhase(c interactor.Contest, st interactor.State, now time.Time) string {
+	switch {
+	case st.Finalized != nil:
+		return stateFinalized
+	case st.Ended != nil:
+		if st.Frozen != nil && st.Thawed == nil {
+			return stateEnded + ", " + stateFrozen
+		}
+		return stateEnded
+	case st.Frozen != nil && st.Thawed == nil:
+		return stateFrozen
+	case st.Started != nil:
+		return stateStarted
+	}
+
+	if c.StartTime == (interactor.ApiTime{}) {
+		if c.CountdownTime != 0 {
+			return statePaused
+		}
+		return stateNotScheduled
+	}
+
+	startTime := c.StartTime.Time()
+	endTime := startTime.Add(c.Duration.Duration())
+	freezeTime := endTime.Add(-c.ScoreboardFreezeDuration.Duration())
+	switch {
+	case now.Before(startTime):
+		return stateScheduled
+	case !now.Before(endTime):
+		return stateEnded
+	case c.ScoreboardFreezeDuration > 0 && !now.Before(freezeTime):
+		return stateFrozen
+	default:
+		return stateStarted
+	}
+}
+
+// formatClock formats a duration as h:mm:ss.
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
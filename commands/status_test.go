@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusTable(t *testing.T) {
+	start := time.Date(2022, 4, 1, 10, 0, 0, 0, time.Local)
+	contest := interactor.Contest{
+		Name:                     "Finals",
+		StartTime:                interactor.ApiTime(start),
+		Duration:                 interactor.ApiRelTime(5 * time.Hour),
+		ScoreboardFreezeDuration: interactor.ApiRelTime(time.Hour),
+	}
+	stamp := interactor.ApiTime(start)
+
+	tests := []struct {
+		name     string
+		contest  interactor.Contest
+		state    interactor.State
+		now      time.Time
+		expected []string
+	}{
+		{
+			name:     "scheduled",
+			contest:  contest,
+			now:      start.Add(-90 * time.Second),
+			expected: []string{"Finals", "scheduled", "in 0:01:30", "", "", "in 4:01:30"},
+		},
+		{
+			name:     "running",
+			contest:  contest,
+			state:    interactor.State{Started: &stamp},
+			now:      start.Add(2*time.Hour + 30*time.Minute),
+			expected: []string{"Finals", "started", "10:00:00", "2:30:00", "2:30:00", "in 1:30:00"},
+		},
+		{
+			name:     "frozen",
+			contest:  contest,
+			now:      start.Add(4*time.Hour + 15*time.Minute),
+			expected: []string{"Finals", "frozen", "10:00:00", "4:15:00", "0:45:00", "frozen"},
+		},
+		{
+			name:     "finalized",
+			contest:  contest,
+			state:    interactor.State{Started: &stamp, Ended: &stamp, Frozen: &stamp, Finalized: &stamp},
+			now:      start.Add(6 * time.Hour),
+			expected: []string{"Finals", "finalized", "10:00:00", "5:00:00", "0:00:00", ""},
+		},
+		{
+			name:     "paused",
+			contest:  interactor.Contest{Name: "Finals", Duration: interactor.ApiRelTime(5 * time.Hour), CountdownTime: interactor.ApiRelTime(10 * time.Minute)},
+			now:      start,
+			expected: []string{"Finals", "countdown paused", "in 0:10:00 (paused)", "", "", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := statusTable(tt.contest, tt.state, tt.now)
+			assert.Equal(t, []rowStr{tt.expected}, table.Rows)
+		})
+	}
+}
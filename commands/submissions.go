@@ -1,21 +1,46 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"net/url"
 	"sort"
 	"strings"
 
+	interactor "github.com/icpctools/api-interactor"
 	"github.com/spf13/cobra"
 )
 
 var submissionsCommand = &cobra.Command{
-	Use:     "submissions",
-	Short:   "List past submissions and their judgements",
+	Use:   "submissions",
+	Short: "List past submissions and their judgements",
+	Long: `List past submissions and their judgements
+
+Only the latest judgement of every submission is shown, unless --all-judgements is given. Use submissions show to see
+the details of a single submission.`,
 	Args:    cobra.NoArgs,
 	RunE:    submissions,
 	PreRunE: configHelper("baseurl"),
 }
 
+var submissionsShowCommand = &cobra.Command{
+	Use:     "show [id]",
+	Short:   "Show the files, judgements and test case runs of a submission",
+	Args:    cobra.ExactArgs(1),
+	RunE:    showSubmission,
+	PreRunE: configHelper("baseurl"),
+}
+
+// run is the result of a single test case, which is not part of the interactor
+type run struct {
+	Id              string                `json:"id"`
+	JudgementId     string                `json:"judgement_id"`
+	Ordinal         int                   `json:"ordinal"`
+	JudgementTypeId string                `json:"judgement_type_id"`
+	ContestTime     interactor.ApiRelTime `json:"contest_time"`
+	RunTime         float64               `json:"run_time"`
+}
+
 func submissions(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 	api, err := contestApi()
@@ -58,62 +83,225 @@ func submissions(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("must be logged in as a team to see your submissions")
 	}
 
+	var problemFilter interactor.Problem
+	if problemId != "" {
+		var hasProblem bool
+		if problemFilter, hasProblem = problemSet(problems).byId(problemId); !hasProblem {
+			return fmt.Errorf("unknown problem %s", problemId)
+		}
+	}
+
 	// sort by submission time
 	sort.Slice(submissions, func(i, j int) bool {
 		return submissions[i].ContestTime.Duration() < submissions[j].ContestTime.Duration()
 	})
 
-	count := 0
+	var shown []interactor.Submission
 	for _, s := range submissions {
-		if strings.EqualFold(s.TeamId, account.TeamId) {
-			count++
+		if !strings.EqualFold(s.TeamId, account.TeamId) {
+			continue
+		}
+		if problemFilter.Id != "" && s.ProblemId != problemFilter.Id {
+			continue
+		}
+		if languageId != "" && !strings.EqualFold(s.LanguageId, languageId) {
+			continue
+		}
+		if s.ContestTime.Duration() < submissionsSince {
+			continue
 		}
+
+		sjudgements, _ := judgementSet(judgements).bySubmissionId(s.Id)
+		if submissionsVerdict != "" {
+			final, isFinal := sjudgements.final()
+			if !isFinal || !strings.EqualFold(final.JudgementTypeId, submissionsVerdict) {
+				continue
+			}
+		}
+
+		shown = append(shown, s)
 	}
 
-	fmt.Printf("\nSubmissions (%d):\n", count)
+	printBanner("\nSubmissions (%d):\n", len(shown))
 	var table = Table{}
-	table.Header = []string{"Time", "Problem", "Language", "Judgement Time", "Judgement"}
-	table.Align = []int{ALIGN_RIGHT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_RIGHT, ALIGN_LEFT}
-	for _, s := range submissions {
-		if s.TeamId == account.TeamId {
-			var row = []string{fmt.Sprintf("%v", s.ContestTime)}
-
-			problem, hasProblem := problemSet(problems).byId(s.ProblemId)
-			if hasProblem {
-				row = append(row, fmt.Sprintf("%s: %s", problem.Label, problem.Name))
-			} else {
-				row = append(row, "unknown")
-			}
+	table.Header = []string{"Id", "Time", "Problem", "Language", "Judgement Time", "Judgement"}
+	table.Align = []int{ALIGN_LEFT, ALIGN_RIGHT, ALIGN_LEFT, ALIGN_LEFT, ALIGN_RIGHT, ALIGN_LEFT}
+	for _, s := range shown {
+		var row = []string{s.Id, fmt.Sprintf("%v", s.ContestTime)}
 
-			language, hasLanguage := languageSet(languages).byId(s.LanguageId)
-			if hasLanguage {
-				row = append(row, language.Name)
-			} else {
-				row = append(row, "unknown")
-			}
+		problem, hasProblem := problemSet(problems).byId(s.ProblemId)
+		if hasProblem {
+			row = append(row, fmt.Sprintf("%s: %s", problem.Label, problem.Name))
+		} else {
+			row = append(row, "unknown")
+		}
+
+		language, hasLanguage := languageSet(languages).byId(s.LanguageId)
+		if hasLanguage {
+			row = append(row, language.Name)
+		} else {
+			row = append(row, "unknown")
+		}
+
+		// Get judgement
+		sjudgements, hasJudgements := judgementSet(judgements).bySubmissionId(s.Id)
+		if !hasJudgements {
+			table.appendRow(append(row, "", "Queued"))
+			continue
+		}
 
-			// Get judgement
-			sjudgements, hasJudgements := judgementSet(judgements).bySubmissionId(s.Id)
-			if hasJudgements {
-				for _, j := range sjudgements {
-					if j.JudgementTypeId != "" {
-						judgementType, hasjudgementType := judgementTypeSet(judgementTypes).byId(j.JudgementTypeId)
-						if hasjudgementType {
-							row = append(row, fmt.Sprintf("%v", j.EndContestTime), fmt.Sprintf("%s (%s)", judgementType.Id, judgementType.Name))
-						} else {
-							row = append(row, fmt.Sprintf("%v", j.EndContestTime), "Unknown judgement")
-						}
-					} else {
-						row = append(row, fmt.Sprintf("%v", j.StartContestTime), "In progress...")
-					}
-				} // todo handle multiple
-			} else {
-				row = append(row, "", "Queued")
+		if !submissionsAllJudgements {
+			table.appendRow(append(row, judgementColumns(latestJudgement(sjudgements), judgementTypes)...))
+			continue
+		}
+
+		// Every other judgement gets its own row below the submission
+		sort.SliceStable(sjudgements, func(i, j int) bool {
+			return sjudgements[i].StartContestTime < sjudgements[j].StartContestTime
+		})
+		for i, j := range sjudgements {
+			if i > 0 {
+				row = make([]string, len(table.Header)-2)
 			}
-			table.appendRow(row)
+			table.appendRow(append(row, judgementColumns(j, judgementTypes)...))
 		}
 	}
 	table.print()
 
 	return nil
 }
+
+func showSubmission(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	api, err := contestApi()
+	if err != nil {
+		return fmt.Errorf("could not connect to the server; %w", err)
+	}
+
+	contest, err := api.Contest()
+	if err != nil {
+		return fmt.Errorf("could not get contest; %w", err)
+	}
+
+	s, err := api.SubmissionById(args[0])
+	if err != nil {
+		return fmt.Errorf("could not get submission %s; %w", args[0], err)
+	}
+
+	problems, err := api.Problems()
+	if err != nil {
+		return fmt.Errorf("could not get problems; %w", err)
+	}
+
+	languages, err := api.Languages()
+	if err != nil {
+		return fmt.Errorf("could not get languages; %w", err)
+	}
+
+	judgementTypes, err := api.JudgementTypes()
+	if err != nil {
+		return fmt.Errorf("could not get judgement types; %w", err)
+	}
+
+	judgements, err := api.Judgements()
+	if err != nil {
+		return fmt.Errorf("could not get judgements; %w", err)
+	}
+
+	problem := s.ProblemId
+	if p, hasProblem := problemSet(problems).byId(s.ProblemId); hasProblem {
+		problem = fmt.Sprintf("%s: %s", p.Label, p.Name)
+	}
+
+	language := s.LanguageId
+	if l, hasLanguage := languageSet(languages).byId(s.LanguageId); hasLanguage {
+		language = l.Name
+	}
+
+	fmt.Fprintf(cmdCtx.stdout, "Submission %s\n", s.Id)
+	fmt.Fprintf(cmdCtx.stdout, "  time:        %v\n", s.ContestTime)
+	fmt.Fprintf(cmdCtx.stdout, "  problem:     %s\n", problem)
+	fmt.Fprintf(cmdCtx.stdout, "  language:    %s\n", language)
+	if s.EntryPoint != "" {
+		fmt.Fprintf(cmdCtx.stdout, "  entry point: %s\n", s.EntryPoint)
+	}
+	for _, f := range s.Files {
+		fmt.Fprintf(cmdCtx.stdout, "  files:       %s (%s)\n", f.Href, f.Mime)
+	}
+
+	sjudgements, _ := judgementSet(judgements).bySubmissionId(s.Id)
+	sort.SliceStable(sjudgements, func(i, j int) bool {
+		return sjudgements[i].StartContestTime < sjudgements[j].StartContestTime
+	})
+
+	raw, err := newRawApi()
+	if err != nil {
+		return err
+	}
+
+	for _, j := range sjudgements {
+		columns := judgementColumns(j, judgementTypes)
+		fmt.Fprintf(cmdCtx.stdout, "\nJudgement %s: %s at %s", j.Id, columns[1], columns[0])
+		if j.MaxRunTime > 0 {
+			fmt.Fprintf(cmdCtx.stdout, ", max run time %.3fs", j.MaxRunTime)
+		}
+		fmt.Fprintln(cmdCtx.stdout)
+
+		var runs []run
+		path := fmt.Sprintf("contests/%s/runs?judgement_id=%s", url.PathEscape(contest.Id), url.QueryEscape(j.Id))
+		if err := raw.getJSON(context.Background(), path, &runs); err != nil {
+			fmt.Fprintf(cmdCtx.stderr, "  could not get runs; %v\n", err)
+			continue
+		}
+
+		// Not every server filters runs by judgement
+		var jruns []run
+		for _, r := range runs {
+			if r.JudgementId == j.Id {
+				jruns = append(jruns, r)
+			}
+		}
+		sort.Slice(jruns, func(a, b int) bool {
+			return jruns[a].Ordinal < jruns[b].Ordinal
+		})
+
+		if len(jruns) == 0 {
+			fmt.Fprintln(cmdCtx.stdout, "  no runs available")
+			continue
+		}
+
+		var table = Table{}
+		table.Header = []string{"Test", "Verdict", "Run Time"}
+		table.Align = []int{ALIGN_RIGHT, ALIGN_LEFT, ALIGN_RIGHT}
+		for _, r := range jruns {
+			table.appendRow([]string{fmt.Sprintf("%d", r.Ordinal), r.JudgementTypeId, fmt.Sprintf("%.3fs", r.RunTime)})
+		}
+		table.print()
+	}
+
+	return nil
+}
+
+// latestJudgement returns the judgement to show for a submission: the most recent finished one, or the one in progress
+// if none has finished yet.
+func latestJudgement(judgements judgementSet) interactor.Judgement {
+	if final, isFinal := judgements.final(); isFinal {
+		return final
+	}
+
+	return judgements[len(judgements)-1]
+}
+
+// judgementColumns returns the time and description of a judgement.
+func judgementColumns(j interactor.Judgement, judgementTypes []interactor.JudgementType) []string {
+	if j.JudgementTypeId == "" {
+		return []string{fmt.Sprintf("%v", j.StartContestTime), "In progress..."}
+	}
+
+	judgementType, hasjudgementType := judgementTypeSet(judgementTypes).byId(j.JudgementTypeId)
+	if hasjudgementType {
+		return []string{fmt.Sprintf("%v", j.EndContestTime), fmt.Sprintf("%s (%s)", judgementType.Id, judgementType.Name)}
+	}
+
+	return []string{fmt.Sprintf("%v", j.EndContestTime), "Unknown judgement"}
+}
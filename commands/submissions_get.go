@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var submissionsGetCommand = &cobra.Command{
+	Use:   "get [id]",
+	Short: "Download the files of a submission",
+	Long: `Download the files of a submission
+
+The files of the submission are unpacked into the directory given by --dir, or submission-<id> by default. Existing
+files are not overwritten unless --force is given. With --resubmit the files are submitted again, with the same
+problem, language and entry point, after confirmation.`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    getSubmission,
+	PreRunE: configHelper("baseurl"),
+}
+
+func getSubmission(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	api, err := contestApi()
+	if err != nil {
+		return fmt.Errorf("could not connect to the server; %w", err)
+	}
+
+	account, err := api.Account()
+	if err != nil {
+		return fmt.Errorf("could not find user account; %w", err)
+	}
+
+	s, err := api.SubmissionById(args[0])
+	if err != nil {
+		return fmt.Errorf("could not get submission %s; %w", args[0], err)
+	}
+
+	if account.TeamId != "" && s.TeamId != account.TeamId {
+		return fmt.Errorf("submission %s is not a submission of your team", s.Id)
+	}
+
+	if len(s.Files) == 0 || s.Files[0].Href == "" {
+		return fmt.Errorf("no files available for submission %s", s.Id)
+	}
+
+	problems, err := api.Problems()
+	if err != nil {
+		return fmt.Errorf("could not get problems; %w", err)
+	}
+
+	languages, err := api.Languages()
+	if err != nil {
+		return fmt.Errorf("could not get languages; %w", err)
+	}
+
+	raw, err := newRawApi()
+	if err != nil {
+		return err
+	}
+
+	resp, err := raw.get(context.Background(), s.Files[0].Href)
+	if err != nil {
+		return fmt.Errorf("could not download files; %w", err)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("could not download files; %w", err)
+	}
+
+	dir := submissionsGetDir
+	if dir == "" {
+		dir = "submission-" + s.Id
+	}
+
+	files, err := extractSubmission(data, dir, force)
+	if err != nil {
+		return err
+	}
+
+	problem, hasProblem := problemSet(problems).byId(s.ProblemId)
+	language, hasLanguage := languageSet(languages).byId(s.LanguageId)
+
+	fmt.Fprintf(cmdCtx.stdout, "Submission %s saved to %s:\n", s.Id, dir)
+	for _, file := range files {
+		fmt.Fprintf(cmdCtx.stdout, "  %s\n", file.path)
+	}
+	if hasProblem {
+		fmt.Fprintf(cmdCtx.stdout, "  problem:     %s: %s\n", problem.Label, problem.Name)
+	} else {
+		fmt.Fprintf(cmdCtx.stdout, "  problem:     %s\n", s.ProblemId)
+	}
+	if hasLanguage {
+		fmt.Fprintf(cmdCtx.stdout, "  language:    %s\n", language.Name)
+	} else {
+		fmt.Fprintf(cmdCtx.stdout, "  language:    %s\n", s.LanguageId)
+	}
+	if s.EntryPoint != "" {
+		fmt.Fprintf(cmdCtx.stdout, "  entry point: %s\n", s.EntryPoint)
+	}
+
+	if !submissionsResubmit {
+		return nil
+	}
+
+	// --force only applies to overwriting the files, the submission is always confirmed. The files keep the names
+	// they had in the archive, so the submission is the same even if it had subdirectories.
+	fmt.Fprintln(cmdCtx.stdout)
+	return submitFiles(cmd, nil, submitOptions{
+		problemId:  s.ProblemId,
+		languageId: s.LanguageId,
+		entryPoint: s.EntryPoint,
+		files:      files,
+	})
+}
+
+// extractSubmission unpacks the files archive of a submission into dir, and returns the files with their name in the
+// archive. Nothing is written if a file already exists, unless overwrite is true.
+func extractSubmission(data []byte, dir string, overwrite bool) ([]submissionFile, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("could not read files archive; %w", err)
+	}
+
+	var files []submissionFile
+	var existing []string
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		name := filepath.FromSlash(f.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) || filepath.Clean(name) != name {
+			return nil, fmt.Errorf("refusing to extract %s outside of %s", f.Name, dir)
+		}
+
+		filename := filepath.Join(dir, name)
+		if _, err := os.Stat(filename); err == nil {
+			existing = append(existing, filename)
+		}
+		files = append(files, submissionFile{path: filename, name: filepath.ToSlash(name), size: int64(f.UncompressedSize64)})
+	}
+
+	if len(existing) > 0 && !overwrite {
+		return nil, fmt.Errorf("refusing to overwrite %s, use --force to overwrite", strings.Join(existing, ", "))
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("files archive is empty")
+	}
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		contents, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		filename := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			return nil, err
+		}
+
+		if err := ioutil.WriteFile(filename, contents, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
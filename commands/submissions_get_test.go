@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/icpctools/cli/mockccs"
+	"github.com/stretchr/testify/assert"
+)
+
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, contents := range files {
+		f, err := w.Create(name)
+		assert.NoError(t, err)
+		_, err = f.Write([]byte(contents))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
+
+func TestExtractSubmission(t *testing.T) {
+	dir := t.TempDir()
+	data := zipFiles(t, map[string]string{"Main.java": "class Main {}", "util/Helper.java": "class Helper {}"})
+
+	files, err := extractSubmission(data, dir, false)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []submissionFile{
+		{path: filepath.Join(dir, "Main.java"), name: "Main.java", size: 13},
+		{path: filepath.Join(dir, "util", "Helper.java"), name: "util/Helper.java", size: 15},
+	}, files)
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "util", "Helper.java"))
+	assert.NoError(t, err)
+	assert.Equal(t, "class Helper {}", string(contents))
+
+	// Existing files are only overwritten when forced
+	_, err = extractSubmission(data, dir, false)
+	assert.Error(t, err)
+	_, err = extractSubmission(data, dir, true)
+	assert.NoError(t, err)
+
+	_, err = extractSubmission(zipFiles(t, map[string]string{"../evil": ""}), dir, true)
+	assert.Error(t, err)
+}
+
+func TestResubmit(t *testing.T) {
+	defer func(f, w, r bool, p, l, e, d string) {
+		force, wait, submissionsResubmit, problemId, languageId, entryPoint, submissionsGetDir = f, w, r, p, l, e, d
+	}(force, wait, submissionsResubmit, problemId, languageId, entryPoint, submissionsGetDir)
+
+	server := mockccs.New(mockccs.Package{
+		Contest:  mockccs.Contest{Id: "practice"},
+		Problems: []mockccs.Problem{{Id: "hello", Label: "A"}},
+	})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	setViper(t, "baseurl", ts.URL)
+	setViper(t, "username", "team1")
+	setViper(t, "password", "team1")
+	stdout, _ := testContext(t, ts.URL, "team1", time.Now(), "y")
+	dir := t.TempDir()
+	writeFiles(t, filepath.Join(dir, "hello"), map[string]string{
+		"Hello.java":      "public class Hello { public static void main(String[] a) {} }",
+		"util/Hello.java": "package util; class Hello {}",
+	})
+
+	force, wait, problemId, languageId, entryPoint = true, false, "", "", ""
+	assert.NoError(t, submit(submitCommand, []string{filepath.Join(dir, "hello")}))
+
+	// The submission is confirmed and made with its own problem, language and entry point, the flags are left alone
+	submissionsResubmit, submissionsGetDir = true, filepath.Join(dir, "get")
+	assert.NoError(t, getSubmission(submissionsGetCommand, []string{"1"}))
+	assert.Contains(t, stdout.String(), "Do you want to submit?")
+	assert.Equal(t, 2, strings.Count(stdout.String(), "accepted at"))
+	assert.True(t, force)
+	assert.Equal(t, []string{"", "", ""}, []string{problemId, languageId, entryPoint})
+
+	// Files in subdirectories keep their path, so files with the same name do not overwrite each other
+	resp, err := rawApiFor("team1", "team1").get(context.Background(), "contests/practice/submissions/2/files")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	assert.ElementsMatch(t, []string{"Hello.java", "util/Hello.java"}, names)
+}
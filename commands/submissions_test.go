@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatestJudgement(t *testing.T) {
+	at := func(minutes int) interactor.ApiRelTime {
+		return interactor.ApiRelTime(time.Duration(minutes) * time.Minute)
+	}
+
+	tests := []struct {
+		name       string
+		judgements judgementSet
+		expected   string
+	}{
+		{"in progress", judgementSet{{Id: "j1", StartContestTime: at(1)}}, "j1"},
+		{"judged", judgementSet{{Id: "j1", JudgementTypeId: "WA", EndContestTime: at(2)}}, "j1"},
+		{"rejudged", judgementSet{{Id: "j1", JudgementTypeId: "WA", EndContestTime: at(2)}, {Id: "j2", JudgementTypeId: "AC", EndContestTime: at(30)}}, "j2"},
+		{"rejudge in progress", judgementSet{{Id: "j1", JudgementTypeId: "WA", EndContestTime: at(2)}, {Id: "j2", StartContestTime: at(30)}}, "j1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, latestJudgement(tt.judgements).Id)
+		})
+	}
+}
+
+func TestJudgementColumns(t *testing.T) {
+	judgementTypes := []interactor.JudgementType{{Id: "AC", Name: "Accepted"}}
+
+	assert.Equal(t, []string{"1m", "In progress..."}, judgementColumns(interactor.Judgement{StartContestTime: interactor.ApiRelTime(time.Minute)}, judgementTypes))
+	assert.Equal(t, []string{"2m", "AC (Accepted)"}, judgementColumns(interactor.Judgement{JudgementTypeId: "AC", EndContestTime: interactor.ApiRelTime(2 * time.Minute)}, judgementTypes))
+	assert.Equal(t, "Unknown judgement", judgementColumns(interactor.Judgement{JudgementTypeId: "XX"}, judgementTypes)[1])
+}
@@ -6,30 +6,104 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 	"unicode"
 
-	"github.com/Songmu/prompter"
 	interactor "github.com/icpctools/api-interactor"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+)
+
+const waitPollInterval = 2 * time.Second
+
+// verdictExitCodes contains the exit code used by submit --wait for common judgement types. Judgement types that are
+// not listed exit with exitCodeSolved or exitCodeRejected, depending on whether they count as solved.
+var verdictExitCodes = map[string]int{
+	"AC":  0,
+	"WA":  2,
+	"TLE": 3,
+	"RTE": 4,
+	"RE":  4,
+	"CE":  5,
+	"OLE": 6,
+	"MLE": 7,
+}
+
+const (
+	exitCodeSolved   = 0
+	exitCodeRejected = 8
+	exitCodeTimeout  = 9
 )
 
 var submitCommand = &cobra.Command{
-	Use:     "submit [file1] <file2> <file3> ...",
-	Short:   "Submit one or more files",
+	Use:   "submit [file or directory] <file2> <file3> ...",
+	Short: "Submit one or more files or a directory",
+	Long: `Submit one or more files or a directory
+
+When a directory is given, all files in it are submitted except version control, editor and build output files, the
+patterns in its .contestignore file and the patterns given by --exclude. Patterns follow the .gitignore syntax, so a
+pattern starting with ! includes files again. The problem and language are detected from
+the file named after a problem, or else the first file with a known extension. Submissions exceeding --max-files or
+--max-size are refused, unless --force is given.
+
+When the language requires an entry point and --entry-point is not given, it is detected from the files: the class with
+the main method for Java, Kotlin, Scala and C#, and the file checking __name__ == "__main__" for Python. The detection
+can be changed or added for other languages in the configuration file, e.g.:
+
+  submit:
+    entry_points:
+      java:
+        aliases: [java, openjdk]
+        main: 'static\s+void\s+main\s*\('
+        class: 'class\s+(\w+)'
+        package: 'package\s+([\w.]+);'
+        format: '{package}{class}'
+
+Available placeholders in the format are {file} (the main file), {name} (the main file without extension), {class}
+(the class matched before main), {package} (the matched package followed by a dot) and {facade} (the Kotlin class of
+the main file).
+
+When --wait is given, the command waits until the submission is judged, prints the verdict and exits with a code
+depending on it: 0 for AC, 2 for WA, 3 for TLE, 4 for RTE, 5 for CE, 6 for OLE, 7 for MLE, 8 for any other rejected
+verdict and 9 when no verdict arrived before the --wait-timeout.`,
 	Args:    cobra.MinimumNArgs(1),
 	RunE:    submit,
 	PreRunE: configHelper("baseurl"),
 }
 
+// submitOptions are the choices for a submission that can be given as flags. The problem, language and entry point
+// are detected from the files when they are empty.
+type submitOptions struct {
+	problemId  string
+	languageId string
+	entryPoint string
+	// force skips the confirmation and turns the limits into warnings
+	force bool
+	test  bool
+	wait  bool
+	// files are submitted with their names as given, like the files of a directory, instead of the arguments
+	files []submissionFile
+}
+
 func submit(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
+	return submitFiles(cmd, args, submitOptions{
+		problemId:  problemId,
+		languageId: languageId,
+		entryPoint: entryPoint,
+		force:      force,
+		test:       testFirst,
+		wait:       wait,
+	})
+}
+
+// submitFiles submits the files and directories in args.
+func submitFiles(cmd *cobra.Command, args []string, opts submitOptions) error {
 	api, err := contestApi()
 	if err != nil {
 		return fmt.Errorf("could not connect to the server; %w", err)
 	}
 
-	contest, err := api.ContestById(viper.GetString("contest"))
+	contest, err := api.Contest()
 	if err != nil {
 		return fmt.Errorf("could not get contest; %w", err)
 	}
@@ -45,90 +119,86 @@ func submit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not get languages; %w", err)
 	}
 
-	// Try to load all the files from the arguments
-	var files interactor.LocalFileReference
-	for _, filename := range args {
-		file, err := os.Open(filename)
+	// Resolve the files and directories in the arguments
+	collected, fromDir := opts.files, len(opts.files) > 0
+	if !fromDir {
+		collected, err = collectSubmissionFiles(args)
 		if err != nil {
-			return fmt.Errorf("could not open file %s; %w", filename, err)
+			return err
 		}
 
-		err = files.FromFile(file)
-		if err != nil {
-			return fmt.Errorf("could not add file %s; %w", filename, err)
+		for _, arg := range args {
+			if info, err := os.Stat(arg); err == nil && info.IsDir() {
+				fromDir = true
+			}
 		}
 	}
 
-	// If the problem or language is not set, use the first file to determine them
-	if problemId == "" || languageId == "" {
-		// Assume first part of the basename can be used to detect problem and the extension can be used to detect language
-		firstFileParts := strings.Split(filepath.Base(args[0]), ".")
-		var extension string
-		if len(firstFileParts) > 1 {
-			extension = strings.ToLower(firstFileParts[len(firstFileParts)-1])
-		}
-
-		if problemId == "" {
-			problemId = strings.ToLower(firstFileParts[0])
-		}
-
-		if languageId == "" {
-			if language, found := languageSet(languages).byExtension(extension); found {
-				languageId = language.Id
-			}
-		}
+	collected = mainFile(collected, fromDir, problems, languages)
+	var paths []string
+	for _, f := range collected {
+		paths = append(paths, f.path)
 	}
 
-	problem, hasProblem := problemSet(problems).byId(problemId)
-	language, hasLanguage := languageSet(languages).byId(languageId)
+	if err := checkSubmissionLimits(cmd, collected, opts.force); err != nil {
+		return err
+	}
 
-	if !hasProblem {
-		return fmt.Errorf("no known problem specified or detected")
+	files, err := addSubmissionFiles(collected)
+	if err != nil {
+		return err
 	}
 
-	if !hasLanguage {
-		return fmt.Errorf("no known language specified or detected")
+	problem, language, err := detectProblemAndLanguageFor(paths, problems, languages, opts.problemId, opts.languageId)
+	if err != nil {
+		return err
 	}
 
-	// Try to auto detect entry point based on hardcoded language logic
+	entryPoint := opts.entryPoint
 	if entryPoint == "" && language.EntryPointRequired {
-		switch language.Id {
-		case "java":
-			// Java: use base name of first file
-			parts := strings.Split(filepath.Base(args[0]), ".")
-			entryPoint = parts[0]
-		case "python", "python2", "python3":
-			// Python: use first file
-			entryPoint = filepath.Base(args[0])
-		case "kotlin":
-			parts := strings.Split(filepath.Base(args[0]), ".")
-			entryPoint = kotlinBaseEntryPoint(parts[0]) + "Kt"
-		}
+		entryPoint = detectEntryPoint(language, paths)
 	}
 
 	if entryPoint == "" && language.EntryPointRequired {
 		return fmt.Errorf("entry point required but not specified nor detected")
 	}
 
-	if !force {
-		fmt.Println("About to submit:")
-		if len(args) == 1 {
-			fmt.Printf("  filename:    %s\n", args[0])
+	if opts.test {
+		fmt.Fprintln(cmdCtx.stdout, "Testing against the samples before submitting...")
+		results, err := testSamples(problem, language, paths, entryPoint)
+		if err != nil {
+			return fmt.Errorf("could not test the samples; %w", err)
+		}
+
+		if len(results) == 0 {
+			fmt.Fprintf(cmdCtx.stdout, "No samples found for problem %s, submitting without testing\n", problem.Label)
+		} else if failed := printSampleResults(results); failed > 0 {
+			return fmt.Errorf("submission refused, %d of %d samples failed", failed, len(results))
+		}
+	}
+
+	if !opts.force {
+		fmt.Fprintln(cmdCtx.stdout, "About to submit:")
+		if fromDir {
+			fmt.Fprintln(cmdCtx.stdout, "  files:")
+			printFileTree(collected)
+		} else if len(paths) == 1 {
+			fmt.Fprintf(cmdCtx.stdout, "  filename:    %s\n", paths[0])
 		} else {
-			fmt.Print("  filenames:  ")
-			for _, filename := range args {
-				fmt.Printf(" %s\n", filename)
+			fmt.Fprint(cmdCtx.stdout, "  filenames:  ")
+			for _, filename := range paths {
+				fmt.Fprintf(cmdCtx.stdout, " %s\n", filename)
 			}
 		}
 
-		fmt.Printf("  contest:     %s\n", contest.Name)
-		fmt.Printf("  problem:     %s\n", problem.Label)
-		fmt.Printf("  language:    %s\n", language.Name)
+		fmt.Fprintf(cmdCtx.stdout, "  contest:     %s\n", contest.Name)
+		fmt.Fprintf(cmdCtx.stdout, "  problem:     %s\n", problem.Label)
+		fmt.Fprintf(cmdCtx.stdout, "  language:    %s\n", language.Name)
 		if entryPoint != "" {
-			fmt.Printf("  entry point: %s\n", entryPoint)
+			fmt.Fprintf(cmdCtx.stdout, "  entry point: %s\n", entryPoint)
 		}
 
-		if !prompter.YN("Do you want to submit?", true) {
+		if !cmdCtx.prompter.YN("Do you want to submit?", true) {
 			return errors.New("submission aborted by user")
 		}
 	}
@@ -138,10 +208,123 @@ func submit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not submit: %w", err)
 	}
 
-	fmt.Println("Submittion accepted at ", submission.ContestTime)
-	return nil
+	fmt.Fprintln(cmdCtx.stdout, "Submittion accepted at ", submission.ContestTime)
+	if !opts.wait {
+		return nil
+	}
+
+	return waitForVerdict(cmd, api, submission)
+}
+
+// waitForVerdict polls the judgements of the given submission until a final one arrives. The verdict is returned as
+// an exitError with a code depending on the judgement type.
+func waitForVerdict(cmd *cobra.Command, api interactor.ContestApi, submission interactor.Submission) error {
+	judgementTypes, err := api.JudgementTypes()
+	if err != nil {
+		return fmt.Errorf("could not get judgement types; %w", err)
+	}
+
+	fmt.Fprintln(cmdCtx.stdout, "Waiting for judgement...")
+	var deadline time.Time
+	if waitTimeout > 0 {
+		deadline = cmdCtx.now().Add(waitTimeout)
+	}
+
+	for {
+		judgements, err := api.Judgements()
+		if err != nil {
+			return fmt.Errorf("could not get judgements; %w", err)
+		}
+
+		sjudgements, _ := judgementSet(judgements).bySubmissionId(submission.Id)
+		if judgement, done := sjudgements.final(); done {
+			judgementType, hasJudgementType := judgementTypeSet(judgementTypes).byId(judgement.JudgementTypeId)
+			if !hasJudgementType {
+				judgementType = interactor.JudgementType{Id: judgement.JudgementTypeId, Name: "Unknown judgement"}
+			}
+
+			fmt.Fprintf(cmdCtx.stdout, "Judged at %v: %s (%s)\n", judgement.EndContestTime, judgementType.Id, judgementType.Name)
+
+			code := verdictExitCode(judgementType)
+			if code == 0 {
+				return nil
+			}
+
+			// The verdict has already been printed, so there is no need for cobra to print it as an error again
+			cmd.SilenceErrors = true
+			return exitError{code: code, err: fmt.Errorf("submission judged %s", judgementType.Id)}
+		}
+
+		if !deadline.IsZero() && cmdCtx.now().After(deadline) {
+			return exitError{code: exitCodeTimeout, err: fmt.Errorf("no judgement received within %v", waitTimeout)}
+		}
+
+		time.Sleep(waitPollInterval)
+	}
+}
+
+func verdictExitCode(judgementType interactor.JudgementType) int {
+	if code, ok := verdictExitCodes[strings.ToUpper(judgementType.Id)]; ok {
+		return code
+	}
+
+	if judgementType.Solved {
+		return exitCodeSolved
+	}
+
+	return exitCodeRejected
+}
+
+// detectProblemAndLanguage returns the problem and language to use for the given files. If the problem or language is
+// not set through the flags, the first file is used to determine them. When the name of the first file is not a
+// problem, the name of its directory is tried.
+func detectProblemAndLanguage(files []string, problems []interactor.Problem, languages []interactor.Language) (interactor.Problem, interactor.Language, error) {
+	return detectProblemAndLanguageFor(files, problems, languages, problemId, languageId)
+}
+
+// detectProblemAndLanguageFor is detectProblemAndLanguage with the given problem and language instead of the flags.
+func detectProblemAndLanguageFor(files []string, problems []interactor.Problem, languages []interactor.Language, givenProblem, givenLanguage string) (interactor.Problem, interactor.Language, error) {
+	pid, lid := givenProblem, givenLanguage
+	if pid == "" || lid == "" {
+		// Assume first part of the basename can be used to detect problem and the extension can be used to detect language
+		firstFileParts := strings.Split(filepath.Base(files[0]), ".")
+		var extension string
+		if len(firstFileParts) > 1 {
+			extension = strings.ToLower(firstFileParts[len(firstFileParts)-1])
+		}
+
+		if pid == "" {
+			pid = strings.ToLower(firstFileParts[0])
+		}
+
+		if lid == "" {
+			if language, found := languageSet(languages).byExtension(extension); found {
+				lid = language.Id
+			}
+		}
+	}
+
+	problem, hasProblem := problemSet(problems).byId(pid)
+	if !hasProblem && givenProblem == "" {
+		if dir, err := filepath.Abs(filepath.Dir(files[0])); err == nil {
+			problem, hasProblem = problemSet(problems).byId(filepath.Base(dir))
+		}
+	}
+	language, hasLanguage := languageSet(languages).byId(lid)
+
+	if !hasProblem {
+		return problem, language, fmt.Errorf("no known problem specified or detected")
+	}
+
+	if !hasLanguage {
+		return problem, language, fmt.Errorf("no known language specified or detected")
+	}
+
+	return problem, language, nil
 }
 
+// kotlinBaseEntryPoint returns the name Kotlin uses for the class of top level functions in a file, without the Kt
+// suffix.
 func kotlinBaseEntryPoint(base string) string {
 	if base == "" {
 		return "_"
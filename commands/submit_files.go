@@ -0,0 +1,305 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// contestIgnoreFile lists the patterns to exclude when submitting a directory, in the same format as .gitignore
+const contestIgnoreFile = ".contestignore"
+
+// defaultExcludes are never submitted when submitting a directory: version control, editor and build output files.
+var defaultExcludes = []string{
+	".git/", ".svn/", ".hg/", ".idea/", ".vscode/", "__pycache__/",
+	"build/", "target/", "out/", "bin/", "obj/",
+	"*.o", "*.obj", "*.class", "*.pyc", "*.exe", "a.out",
+	"*.swp", "*.swo", "*~", "#*#", ".DS_Store",
+	contestIgnoreFile,
+}
+
+// submissionFile is a file to submit, with its name in the submitted archive.
+type submissionFile struct {
+	path string
+	name string
+	size int64
+}
+
+// collectSubmissionFiles resolves the arguments of submit into files. Directories are walked, skipping the default
+// excludes, the patterns in their .contestignore file and the patterns given through --exclude or submit.exclude in
+// the configuration file. Files in a directory are named relative to it.
+func collectSubmissionFiles(args []string) ([]submissionFile, error) {
+	var files []submissionFile
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, fmt.Errorf("could not open file %s; %w", arg, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, submissionFile{path: arg, name: filepath.Base(arg), size: info.Size()})
+			continue
+		}
+
+		excludes := append(append([]string(nil), defaultExcludes...), viper.GetStringSlice("submit.exclude")...)
+		excludes = append(excludes, submitExcludes...)
+		ignored, err := readIgnoreFile(filepath.Join(arg, contestIgnoreFile))
+		if err != nil {
+			return nil, err
+		}
+		excludes = append(excludes, ignored...)
+
+		err = filepath.Walk(arg, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(arg, p)
+			if err != nil || rel == "." {
+				return err
+			}
+
+			name := filepath.ToSlash(rel)
+			if excluded(name, info.IsDir(), excludes) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if info.Mode().IsRegular() {
+				files = append(files, submissionFile{path: p, name: name, size: info.Size()})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not read directory %s; %w", arg, err)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("no files to submit")
+	}
+
+	return files, nil
+}
+
+// readIgnoreFile returns the patterns in an ignore file, skipping empty lines and comments. A missing file has no
+// patterns.
+func readIgnoreFile(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read %s; %w", filename, err)
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+
+	return patterns, scanner.Err()
+}
+
+// excluded returns whether the slash separated name matches the patterns, following the rules of .gitignore: the last
+// pattern that matches decides, and patterns starting with ! include the name again. Patterns ending in a slash only
+// match directories. Patterns containing another slash are matched against the full name, other patterns against the
+// name in any directory. ** matches any number of directories. Files in an excluded directory can not be included
+// again, as the directory is not looked into.
+func excluded(name string, isDir bool, patterns []string) bool {
+	var result bool
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+
+		if !strings.Contains(pattern, "/") {
+			pattern = "**/" + pattern
+		}
+
+		if matchSegments(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), strings.Split(name, "/")) {
+			result = !negated
+		}
+	}
+
+	return result
+}
+
+// matchSegments matches the elements of a name against the elements of a pattern, in which ** matches any number of
+// elements. A trailing ** only matches what is inside a directory, not the directory itself.
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(name) > 0
+		}
+
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+
+	if matched, _ := path.Match(pattern[0], name[0]); !matched {
+		return false
+	}
+
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// checkSubmissionLimits returns an error when the files exceed the file count or total size limit. When forced, the
+// limits only result in a warning.
+func checkSubmissionLimits(cmd *cobra.Command, files []submissionFile, forced bool) error {
+	maxFiles := submitMaxFiles
+	if f := cmd.Flag("max-files"); (f == nil || !f.Changed) && viper.IsSet("submit.max_files") {
+		maxFiles = viper.GetInt("submit.max_files")
+	}
+
+	maxSize := submitMaxSize
+	if f := cmd.Flag("max-size"); (f == nil || !f.Changed) && viper.IsSet("submit.max_size") {
+		maxSize = viper.GetInt("submit.max_size")
+	}
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+
+	var problems []string
+	if maxFiles > 0 && len(files) > maxFiles {
+		problems = append(problems, fmt.Sprintf("%d files exceed the limit of %d files", len(files), maxFiles))
+	}
+	if maxSize > 0 && total > int64(maxSize)*1024 {
+		problems = append(problems, fmt.Sprintf("%s exceeds the limit of %d KiB", formatSize(total), maxSize))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if forced {
+		fmt.Fprintf(cmdCtx.stderr, "Warning: %s\n", strings.Join(problems, ", "))
+		return nil
+	}
+
+	return fmt.Errorf("submission refused, %s; check the excluded files or use --force", strings.Join(problems, ", "))
+}
+
+// mainFile moves the file used to detect the problem, language and entry point to the front. When only files are
+// submitted, it is the first one. When a directory is submitted, it is the first file named after a problem with the
+// extension of a language, otherwise the first file with the extension of a language.
+func mainFile(files []submissionFile, fromDir bool, problems []interactor.Problem, languages []interactor.Language) []submissionFile {
+	if !fromDir {
+		return files
+	}
+
+	main := -1
+	for i, f := range files {
+		parts := strings.Split(path.Base(f.name), ".")
+		if len(parts) < 2 {
+			continue
+		}
+
+		if _, found := languageSet(languages).byExtension(strings.ToLower(parts[len(parts)-1])); !found {
+			continue
+		}
+
+		if _, found := problemSet(problems).byId(parts[0]); found {
+			main = i
+			break
+		}
+
+		if main == -1 {
+			main = i
+		}
+	}
+
+	if main <= 0 {
+		return files
+	}
+
+	ordered := append([]submissionFile{files[main]}, files[:main]...)
+	return append(ordered, files[main+1:]...)
+}
+
+// addSubmissionFiles adds the files to the reference sent to the API, keeping their names.
+func addSubmissionFiles(files []submissionFile) (interactor.LocalFileReference, error) {
+	var reference interactor.LocalFileReference
+	for _, f := range files {
+		contents, err := ioutil.ReadFile(f.path)
+		if err != nil {
+			return reference, fmt.Errorf("could not read file %s; %w", f.path, err)
+		}
+
+		if err := reference.FromString(f.name, string(contents)); err != nil {
+			return reference, fmt.Errorf("could not add file %s; %w", f.path, err)
+		}
+	}
+
+	return reference, nil
+}
+
+// printFileTree prints the names of the files as a tree, with their sizes.
+func printFileTree(files []submissionFile) {
+	sorted := append([]submissionFile(nil), files...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+
+	printed := map[string]bool{}
+	var total int64
+	for _, f := range sorted {
+		parts := strings.Split(f.name, "/")
+		for i := range parts[:len(parts)-1] {
+			dir := strings.Join(parts[:i+1], "/")
+			if !printed[dir] {
+				printed[dir] = true
+				fmt.Fprintf(cmdCtx.stdout, "    %s%s/\n", strings.Repeat("  ", i), parts[i])
+			}
+		}
+
+		fmt.Fprintf(cmdCtx.stdout, "    %s%s (%s)\n", strings.Repeat("  ", len(parts)-1), parts[len(parts)-1], formatSize(f.size))
+		total += f.size
+	}
+
+	fmt.Fprintf(cmdCtx.stdout, "  %d files, %s in total\n", len(files), formatSize(total))
+}
+
+func formatSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	return fmt.Sprintf("%.1f KiB", float64(size)/1024)
+}
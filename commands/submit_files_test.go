@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, contents := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(contents), 0644))
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	tests := []struct {
+		name     string
+		isDir    bool
+		patterns []string
+		expected bool
+	}{
+		{"main.cpp", false, []string{"*.o"}, false},
+		{"main.o", false, []string{"*.o"}, true},
+		{"src/main.o", false, []string{"*.o"}, true},
+		{"build", true, []string{"build/"}, true},
+		{"build", false, []string{"build/"}, false},
+		{"src/gen/a.java", false, []string{"src/gen/*"}, true},
+		{"gen/a.java", false, []string{"/src/gen/*"}, false},
+		{"notes.txt", false, []string{"*.md", "notes.*"}, true},
+		{"src/gen", true, []string{"src/gen"}, true},
+		{"lib/src/gen", true, []string{"src/gen"}, false},
+		{"a/b/c/gen.txt", false, []string{"**/gen.txt"}, true},
+		{"a/b/gen.txt", false, []string{"a/**/gen.txt"}, true},
+		{"a/gen.txt", false, []string{"a/**/gen.txt"}, true},
+		{"logs", true, []string{"logs/**"}, false},
+		{"logs/x/y.log", false, []string{"logs/**"}, true},
+		{"debug.log", false, []string{"*.log", "!debug.log"}, false},
+		{"error.log", false, []string{"*.log", "!debug.log"}, true},
+		{"debug.log", false, []string{"!debug.log", "*.log"}, true},
+		{"build", true, []string{"build/", "!build/"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, excluded(test.name, test.isDir, test.patterns))
+		})
+	}
+}
+
+func TestCollectSubmissionFiles(t *testing.T) {
+	defer func(e []string) { submitExcludes = e }(submitExcludes)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.cpp":             "int main() {}",
+		"util/helper.h":     "#pragma once",
+		"util/helper.o":     "binary",
+		".git/HEAD":         "ref: refs/heads/main",
+		"main.cpp.swp":      "swap",
+		"notes/todo.txt":    "todo",
+		"input.txt":         "1 2",
+		".contestignore":    "# local notes\nnotes/\n!keep.o\n",
+		"util/keep.o":       "binary",
+		"build/a.out":       "binary",
+		"util/generated.py": "print()",
+	})
+
+	setViper(t, "submit.exclude", []string{"*.py"})
+	submitExcludes = []string{"input.txt"}
+
+	files, err := collectSubmissionFiles([]string{dir})
+	assert.NoError(t, err)
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.name)
+	}
+	assert.ElementsMatch(t, []string{"a.cpp", "util/helper.h", "util/keep.o"}, names)
+
+	_, err = collectSubmissionFiles([]string{filepath.Join(dir, "missing.cpp")})
+	assert.EqualError(t, err, "could not open file "+filepath.Join(dir, "missing.cpp")+"; stat "+filepath.Join(dir, "missing.cpp")+": no such file or directory")
+
+	_, err = collectSubmissionFiles([]string{filepath.Join(dir, "build")})
+	assert.EqualError(t, err, "no files to submit")
+}
+
+func TestCheckSubmissionLimits(t *testing.T) {
+	defer func(f, s int) { submitMaxFiles, submitMaxSize = f, s }(submitMaxFiles, submitMaxSize)
+
+	files := []submissionFile{{name: "a.cpp", size: 1024}, {name: "b.h", size: 2048}}
+	cmd := &cobra.Command{}
+
+	submitMaxFiles, submitMaxSize = 2, 3
+	assert.NoError(t, checkSubmissionLimits(cmd, files, false))
+
+	submitMaxFiles, submitMaxSize = 1, 2
+	assert.EqualError(t, checkSubmissionLimits(cmd, files, false), "submission refused, 2 files exceed the limit of 1 files, 3.0 KiB exceeds the limit of 2 KiB; check the excluded files or use --force")
+
+	// When forced the limits are a warning, which is not part of the output
+	stdout, stderr := testContext(t, "", "", time.Time{})
+	assert.NoError(t, checkSubmissionLimits(cmd, files, true))
+	assert.Empty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "Warning: 2 files exceed the limit of 1 files")
+
+	// The configuration file is used when the flags are not given
+	setViper(t, "submit.max_files", 0)
+	setViper(t, "submit.max_size", 0)
+	assert.NoError(t, checkSubmissionLimits(cmd, files, false))
+}
+
+func TestMainFile(t *testing.T) {
+	problems := []interactor.Problem{{Id: "hello", Label: "A"}}
+	languages := []interactor.Language{{Id: "cpp", Extensions: []string{"cpp", "h"}}}
+
+	tests := []struct {
+		name     string
+		files    []string
+		fromDir  bool
+		expected string
+	}{
+		{"files keep their order", []string{"util.h", "hello.cpp"}, false, "util.h"},
+		{"named after problem", []string{"README", "util.h", "hello.cpp"}, true, "hello.cpp"},
+		{"named after label", []string{"util.h", "a.cpp"}, true, "a.cpp"},
+		{"known extension", []string{"README", "main.cpp", "util.h"}, true, "main.cpp"},
+		{"no known extension", []string{"README", "Makefile"}, true, "README"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var files []submissionFile
+			for _, name := range test.files {
+				files = append(files, submissionFile{name: name})
+			}
+
+			ordered := mainFile(files, test.fromDir, problems, languages)
+			assert.Equal(t, test.expected, ordered[0].name)
+			assert.Len(t, ordered, len(files))
+		})
+	}
+}
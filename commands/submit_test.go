@@ -1,8 +1,15 @@
 package commands
 
 import (
-	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/icpctools/cli/mockccs"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestKotlinBaseEntryPoint(t *testing.T) {
@@ -24,3 +31,69 @@ func TestKotlinBaseEntryPoint(t *testing.T) {
 		})
 	}
 }
+
+func TestVerdictExitCode(t *testing.T) {
+	testcases := []struct {
+		judgementType interactor.JudgementType
+		expectedCode  int
+	}{
+		{interactor.JudgementType{Id: "AC", Solved: true}, 0},
+		{interactor.JudgementType{Id: "wa", Penalty: true}, 2},
+		{interactor.JudgementType{Id: "TLE", Penalty: true}, 3},
+		{interactor.JudgementType{Id: "CE"}, 5},
+		{interactor.JudgementType{Id: "XYZ", Solved: true}, exitCodeSolved},
+		{interactor.JudgementType{Id: "XYZ", Penalty: true}, exitCodeRejected},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.judgementType.Id, func(t *testing.T) {
+			assert.EqualValues(t, tc.expectedCode, verdictExitCode(tc.judgementType))
+		})
+	}
+}
+
+func TestFinalJudgement(t *testing.T) {
+	judgements := judgementSet{
+		{Id: "1", SubmissionId: "s", JudgementTypeId: "WA", EndContestTime: 10},
+		{Id: "2", SubmissionId: "s", JudgementTypeId: "AC", EndContestTime: 20},
+		{Id: "3", SubmissionId: "s", StartContestTime: 30},
+	}
+
+	final, done := judgements.final()
+	assert.True(t, done)
+	assert.EqualValues(t, "2", final.Id)
+
+	_, done = judgementSet{{Id: "4", SubmissionId: "s"}}.final()
+	assert.False(t, done)
+}
+
+func TestSubmitWithMockServer(t *testing.T) {
+	defer func(f, w bool, p, l, e string) {
+		force, wait, problemId, languageId, entryPoint = f, w, p, l, e
+	}(force, wait, problemId, languageId, entryPoint)
+
+	server := mockccs.New(mockccs.Package{
+		Contest:  mockccs.Contest{Id: "practice"},
+		Problems: []mockccs.Problem{{Id: "hello", Label: "A"}, {Id: "sum", Label: "B"}},
+	})
+	server.Verdicts = map[string]string{"B": "WA"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	setViper(t, "baseurl", ts.URL)
+	setViper(t, "username", "team1")
+	setViper(t, "password", "team1")
+	setViper(t, "cache.dir", t.TempDir())
+	force, wait, waitTimeout = true, true, time.Minute
+	problemId, languageId, entryPoint = "", "", ""
+
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "Hello.java"), []byte("public class Hello { public static void main(String[] a) {} }"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "b.py"), []byte("print(1)"), 0644))
+
+	assert.NoError(t, submit(submitCommand, []string{filepath.Join(dir, "Hello.java")}))
+
+	err := submit(submitCommand, []string{filepath.Join(dir, "b.py")})
+	assert.EqualError(t, err, "submission judged WA")
+	assert.Equal(t, 2, ExitCode(err))
+}
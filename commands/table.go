@@ -1,7 +1,17 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
 )
 
 type rowStr []string
@@ -9,62 +19,232 @@ type rowStr []string
 const ALIGN_LEFT = 0
 const ALIGN_RIGHT = 1
 
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputCSV   = "csv"
+	outputTSV   = "tsv"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML, outputCSV, outputTSV}
+
+var ansiEscape = regexp.MustCompile("\033\\[[0-9;]*m")
+
 type Table struct {
 	Header rowStr
 	Rows   []rowStr
 	Align  []int
 }
 
+// record is a single table row keyed by column, which marshals with its keys in column order.
+type record struct {
+	keys   []string
+	values []string
+}
+
 func (table *Table) appendRow(row []string) {
 	table.Rows = append(table.Rows, row)
 }
 
+// machineOutput returns whether the selected output format is meant to be consumed by tools rather than humans.
+func machineOutput() bool {
+	return outputFormat != outputTable
+}
+
+// printBanner prints informational text such as table titles, but only when the output is meant for humans.
+func printBanner(format string, a ...interface{}) {
+	if machineOutput() {
+		return
+	}
+
+	fmt.Fprintf(cmdCtx.stdout, format, a...)
+}
+
+func validateOutputFormat() error {
+	for _, f := range outputFormats {
+		if outputFormat == f {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown output format '%s', expected one of: %s", outputFormat, strings.Join(outputFormats, ", "))
+}
+
 func (table Table) print() error {
+	return table.write(cmdCtx.stdout)
+}
+
+// write renders the table to w in the selected output format.
+func (table Table) write(w io.Writer) error {
+	switch outputFormat {
+	case outputJSON:
+		return table.writeJSON(w)
+	case outputYAML:
+		return table.writeYAML(w)
+	case outputCSV:
+		return table.writeSeparated(w, ',')
+	case outputTSV:
+		return table.writeSeparated(w, '\t')
+	default:
+		return table.writeText(w)
+	}
+}
+
+func (table Table) writeText(w io.Writer) error {
 	// determine the amount of padding needed
 	var numCol = len(table.Header)
 	var maxLength []int = make([]int, numCol)
-	var format []string = make([]string, numCol)
 
 	// find max header width
 	for i, s := range table.Header {
-		if maxLength[i] < len(s) {
-			maxLength[i] = len(s)
+		if maxLength[i] < displayWidth(s) {
+			maxLength[i] = displayWidth(s)
 		}
 	}
 
 	// find max cell width
 	for _, r := range table.Rows {
 		for i, s := range r {
-			if maxLength[i] < len(s) {
-				maxLength[i] = len(s)
+			if maxLength[i] < displayWidth(s) {
+				maxLength[i] = displayWidth(s)
 			}
 		}
 	}
 
-	// create format for each column, respecting width and alignment
-	for i := range table.Header {
-		if table.Align[i] == ALIGN_LEFT {
-			format[i] = fmt.Sprintf(" %%-%vv ", maxLength[i])
-		} else {
-			format[i] = fmt.Sprintf(" %%%vv ", maxLength[i])
-		}
-	}
-
 	// output header bold and underlined
-	fmt.Printf("  \033[1;4m")
+	fmt.Fprintf(w, "  \033[1;4m")
 	for i, k := range table.Header {
-		fmt.Printf(format[i], k)
+		fmt.Fprint(w, table.pad(i, k, maxLength[i]))
 	}
-	fmt.Printf("\033[0m\n")
+	fmt.Fprintf(w, "\033[0m\n")
 
 	// output each cell
 	for _, r := range table.Rows {
-		fmt.Printf("  ")
+		fmt.Fprintf(w, "  ")
 		for i, s := range r {
-			fmt.Printf(format[i], s)
+			fmt.Fprint(w, table.pad(i, s, maxLength[i]))
 		}
-		fmt.Printf("\n")
+		fmt.Fprintf(w, "\n")
 	}
 
 	return nil
 }
+
+// pad pads a cell of the given column to the width, respecting the alignment of the column.
+func (table Table) pad(column int, s string, width int) string {
+	padding := strings.Repeat(" ", width-displayWidth(s))
+	if table.Align[column] == ALIGN_LEFT {
+		return " " + s + padding + " "
+	}
+
+	return " " + padding + s + " "
+}
+
+// displayWidth returns the number of characters s takes up in a terminal, ignoring ANSI escape sequences.
+func displayWidth(s string) int {
+	return utf8.RuneCountInString(ansiEscape.ReplaceAllString(s, ""))
+}
+
+func (table Table) writeSeparated(w io.Writer, separator rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = separator
+	if err := writer.Write(table.Header); err != nil {
+		return err
+	}
+
+	for _, r := range table.Rows {
+		if err := writer.Write(r); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (table Table) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(table.records())
+}
+
+func (table Table) writeYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	if err := encoder.Encode(table.records()); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// records converts the rows of the table to records keyed by the column headers.
+func (table Table) records() []record {
+	keys := make([]string, len(table.Header))
+	for i, h := range table.Header {
+		keys[i] = columnKey(h)
+	}
+
+	records := make([]record, len(table.Rows))
+	for i, r := range table.Rows {
+		records[i] = record{keys: keys, values: r}
+	}
+
+	return records
+}
+
+// columnKey converts a column header such as "Judgement Time" to a key such as "judgement_time".
+func columnKey(header string) string {
+	fields := strings.FieldsFunc(header, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.ToLower(strings.Join(fields, "_"))
+}
+
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := json.Marshal(r.value(i))
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func (r record) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i, key := range r.keys {
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: r.value(i)},
+		)
+	}
+
+	return node, nil
+}
+
+func (r record) value(i int) string {
+	if i < len(r.values) {
+		return r.values[i]
+	}
+
+	return ""
+}
@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableMachineOutput(t *testing.T) {
+	table := Table{
+		Header: []string{"Label", "Judgement Time"},
+		Align:  []int{ALIGN_LEFT, ALIGN_RIGHT},
+	}
+	table.appendRow([]string{"A", "1m"})
+	table.appendRow([]string{"B, C", "2m"})
+
+	testcases := []struct {
+		format         string
+		expectedOutput string
+	}{
+		{outputJSON, "[\n  {\n    \"label\": \"A\",\n    \"judgement_time\": \"1m\"\n  },\n  {\n    \"label\": \"B, C\",\n    \"judgement_time\": \"2m\"\n  }\n]\n"},
+		{outputYAML, "- label: A\n  judgement_time: 1m\n- label: B, C\n  judgement_time: 2m\n"},
+		{outputCSV, "Label,Judgement Time\nA,1m\n\"B, C\",2m\n"},
+		{outputTSV, "Label\tJudgement Time\nA\t1m\nB, C\t2m\n"},
+	}
+
+	defer func(format string) { outputFormat = format }(outputFormat)
+	for _, tc := range testcases {
+		t.Run(tc.format, func(t *testing.T) {
+			outputFormat = tc.format
+			var buf bytes.Buffer
+			assert.NoError(t, table.write(&buf))
+			assert.EqualValues(t, tc.expectedOutput, buf.String())
+		})
+	}
+}
+
+func TestTableTextOutputIgnoresEscapes(t *testing.T) {
+	table := Table{
+		Header: []string{"Change", "Rank"},
+		Align:  []int{ALIGN_LEFT, ALIGN_RIGHT},
+	}
+	table.appendRow([]string{colorGreen + "▲2" + colorReset, "1"})
+	table.appendRow([]string{"", "12"})
+
+	defer func(format string) { outputFormat = format }(outputFormat)
+	outputFormat = outputTable
+
+	var buf bytes.Buffer
+	assert.NoError(t, table.write(&buf))
+	assert.EqualValues(t, "  \033[1;4m Change  Rank \033[0m\n"+
+		"   "+colorGreen+"▲2"+colorReset+"         1 \n"+
+		"             12 \n", buf.String())
+}
@@ -9,6 +9,6 @@ import (
 func main() {
 	err := commands.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(commands.ExitCode(err))
 	}
 }
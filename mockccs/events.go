@@ -0,0 +1,91 @@
+package mockccs
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keepAliveInterval is how often a newline is sent on an idle event feed
+const keepAliveInterval = 30 * time.Second
+
+// event is an event of the event feed, in the 2022-07 format.
+type event struct {
+	Type  string          `json:"type"`
+	Id    string          `json:"id,omitempty"`
+	Data  json.RawMessage `json:"data"`
+	Token string          `json:"token"`
+
+	// object is the data of the event, to check whether it is visible to an account
+	object interface{}
+}
+
+// eventFeed streams the events visible to the account as NDJSON, starting after the since_token and only including
+// the given types. The feed stays open for new events, unless stream=false is given.
+func (s *Server) eventFeed(w http.ResponseWriter, r *http.Request, account *Account) {
+	query := r.URL.Query()
+
+	var next int
+	if since := query.Get("since_token"); since != "" {
+		token, err := strconv.Atoi(since)
+		if err != nil || token < 0 {
+			writeError(w, http.StatusBadRequest, "invalid since_token "+since)
+			return
+		}
+		next = token
+	}
+
+	types := map[string]bool{}
+	for _, t := range strings.Split(query.Get("types"), ",") {
+		if t != "" {
+			types[t] = true
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		s.mu.Lock()
+		var pending []event
+		if next < len(s.events) {
+			for _, ev := range s.events[next:] {
+				if (len(types) == 0 || types[ev.Type]) && s.visible(account, ev.object) {
+					pending = append(pending, ev)
+				}
+			}
+			next = len(s.events)
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		for _, ev := range pending {
+			if err := encoder.Encode(ev); err != nil {
+				return
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if query.Get("stream") == "false" {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		case <-keepAlive.C:
+			if _, err := w.Write([]byte("\n")); err != nil {
+				return
+			}
+		}
+	}
+}
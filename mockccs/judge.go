@@ -0,0 +1,292 @@
+package mockccs
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// expectedResultsMarker can be put in a comment of a submitted file to ask for a verdict
+const expectedResultsMarker = "@EXPECTED_RESULTS@:"
+
+// runTime is the run time reported for every test case
+const runTime = 0.1
+
+// verdict returns the judgement type for a submission: the one asked for in the files, the one configured for the
+// problem, or the default.
+func (s *Server) verdict(problem Problem, data []byte) string {
+	if archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+		for _, f := range archive.File {
+			rc, err := f.Open()
+			if err != nil {
+				continue
+			}
+
+			scanner := bufio.NewScanner(rc)
+			for scanner.Scan() {
+				line := scanner.Text()
+				i := strings.Index(line, expectedResultsMarker)
+				if i < 0 {
+					continue
+				}
+
+				fields := strings.FieldsFunc(line[i+len(expectedResultsMarker):], func(r rune) bool {
+					return r == ' ' || r == ',' || r == '\t'
+				})
+				if len(fields) > 0 {
+					rc.Close()
+					return s.judgementTypeId(fields[0])
+				}
+			}
+			rc.Close()
+		}
+	}
+
+	for _, key := range []string{problem.Id, problem.Label} {
+		if v, ok := s.Verdicts[key]; ok {
+			return s.judgementTypeId(v)
+		}
+	}
+
+	if s.DefaultVerdict != "" {
+		return s.judgementTypeId(s.DefaultVerdict)
+	}
+
+	return "AC"
+}
+
+// judgementTypeId returns the id of the judgement type matching the verdict, which may also be a verdict name used in
+// @EXPECTED_RESULTS@ annotations.
+func (s *Server) judgementTypeId(verdict string) string {
+	verdict = strings.ToUpper(strings.TrimSpace(verdict))
+	if id, ok := expectedResults[verdict]; ok {
+		verdict = id
+	}
+
+	for _, jt := range s.judgementTypes {
+		if strings.EqualFold(jt.Id, verdict) {
+			return jt.Id
+		}
+	}
+
+	return verdict
+}
+
+// judge finishes a judgement with the verdict. Accepted submissions pass every test case, others fail the last one,
+// and submissions that do not compile are not run. The lock must be held.
+func (s *Server) judge(id, verdict string) {
+	var j *Judgement
+	for i := range s.judgements {
+		if s.judgements[i].Id == id {
+			j = &s.judgements[i]
+		}
+	}
+	if j == nil {
+		return
+	}
+
+	var problem Problem
+	for _, sub := range s.submissions {
+		if sub.Id == j.SubmissionId {
+			problem, _ = s.problemById(sub.ProblemId)
+		}
+	}
+
+	now := s.Now()
+	if verdict != "CE" {
+		tests := problem.TestDataCount
+		if tests == 0 {
+			tests = 1
+		}
+
+		for ordinal := 1; ordinal <= tests; ordinal++ {
+			r := Run{
+				Id:              strconv.Itoa(len(s.runs) + 1),
+				JudgementId:     j.Id,
+				Ordinal:         ordinal,
+				JudgementTypeId: "AC",
+				Time:            now,
+				ContestTime:     s.contestTime(now),
+				RunTime:         runTime,
+			}
+			if ordinal == tests {
+				r.JudgementTypeId = verdict
+			}
+			s.runs = append(s.runs, r)
+			s.addEvent("runs", r.Id, r)
+		}
+		j.MaxRunTime = runTime
+	}
+
+	contestTime := s.contestTime(now)
+	j.JudgementTypeId = verdict
+	j.EndTime = &now
+	j.EndContestTime = &contestTime
+	s.addEvent("judgements", j.Id, *j)
+}
+
+// answer replies to a clarification of a team with the ClarificationAnswer. The lock must be held.
+func (s *Server) answer(question Clarification) {
+	now := s.Now()
+	reply := Clarification{
+		Id:          strconv.Itoa(len(s.clarifications) + 1),
+		ToTeamId:    question.FromTeamId,
+		ReplyToId:   question.Id,
+		ProblemId:   question.ProblemId,
+		Text:        s.ClarificationAnswer,
+		Time:        now,
+		ContestTime: s.contestTime(now),
+	}
+
+	s.clarifications = append(s.clarifications, reply)
+	s.addEvent("clarifications", reply.Id, reply)
+}
+
+// state returns the state of the contest at the given time. The contest is never finalized.
+func (s *Server) state(now time.Time) State {
+	var state State
+	start := *s.contest.StartTime
+	end := start.Add(s.contest.Duration.Duration())
+	freeze := end.Add(-s.contest.ScoreboardFreezeDuration.Duration())
+
+	if !now.Before(start) {
+		state.Started = &start
+	}
+	if s.contest.ScoreboardFreezeDuration > 0 && !now.Before(freeze) {
+		state.Frozen = &freeze
+	}
+	if !now.Before(end) {
+		state.Ended = &end
+	}
+
+	return state
+}
+
+// scoreboard computes the scoreboard at the given time, using the final judgements. The scoreboard is not frozen.
+func (s *Server) scoreboard(now time.Time) Scoreboard {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	verdicts := map[string]string{}
+	for _, j := range s.judgements {
+		if j.JudgementTypeId != "" {
+			verdicts[j.SubmissionId] = j.JudgementTypeId
+		}
+	}
+
+	judgementTypes := map[string]JudgementType{}
+	for _, jt := range s.judgementTypes {
+		judgementTypes[jt.Id] = jt
+	}
+
+	submissions := append([]Submission(nil), s.submissions...)
+	sort.SliceStable(submissions, func(i, j int) bool {
+		return submissions[i].ContestTime < submissions[j].ContestTime
+	})
+
+	var rows []ScoreboardRow
+	for _, t := range s.teams {
+		row := ScoreboardRow{TeamId: t.Id, Problems: []ScoreProblem{}}
+		for _, p := range s.problems {
+			sp := ScoreProblem{ProblemId: p.Id}
+			var penalties int
+			for _, sub := range submissions {
+				if sub.TeamId != t.Id || sub.ProblemId != p.Id || sp.Solved {
+					continue
+				}
+
+				verdict, judged := verdicts[sub.Id]
+				if !judged {
+					sp.NumPending++
+					continue
+				}
+
+				sp.NumJudged++
+				jt := judgementTypes[verdict]
+				if jt.Solved {
+					sp.Solved = true
+					sp.Time = int(sub.ContestTime.Duration().Minutes())
+				} else if jt.Penalty {
+					penalties++
+				}
+			}
+
+			if sp.Solved {
+				row.Score.NumSolved++
+				row.Score.TotalTime += sp.Time + penalties*s.contest.PenaltyTime
+			}
+			row.Problems = append(row.Problems, sp)
+		}
+		rows = append(rows, row)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Score.NumSolved != rows[j].Score.NumSolved {
+			return rows[i].Score.NumSolved > rows[j].Score.NumSolved
+		}
+		return rows[i].Score.TotalTime < rows[j].Score.TotalTime
+	})
+	for i := range rows {
+		rows[i].Rank = i + 1
+		if i > 0 && rows[i].Score == rows[i-1].Score {
+			rows[i].Rank = rows[i-1].Rank
+		}
+	}
+
+	return Scoreboard{
+		Time:        now,
+		ContestTime: s.contestTime(now),
+		State:       s.state(now),
+		Rows:        rows,
+	}
+}
+
+// problemPackage zips problem.yaml and the samples of a problem directory.
+func problemPackage(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+		if name != "problem.yaml" && !strings.HasPrefix(name, "data/sample/") {
+			return nil
+		}
+
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		f, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(contents)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,793 @@
+// Package mockccs provides an in-process Contest API server, to exercise the CLI in tests and to rehearse a contest
+// without network. It is seeded from a contest package, accepts submissions and clarifications of teams, and judges
+// submissions with configurable verdicts without running them.
+//
+// The server implements http.Handler, so it can be used with httptest:
+//
+//	srv, err := mockccs.Load("testdata/contest")
+//	ts := httptest.NewServer(srv)
+//	defer ts.Close()
+package mockccs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	accountTypeTeam  = "team"
+	accountTypeJudge = "judge"
+	accountTypeAdmin = "admin"
+
+	defaultDuration = 5 * time.Hour
+	defaultPenalty  = 20
+)
+
+var (
+	defaultLanguages = []Language{
+		{Id: "c", Name: "C", Extensions: []string{"c"}},
+		{Id: "cpp", Name: "C++", Extensions: []string{"cpp", "cc", "cxx", "c++"}},
+		{Id: "java", Name: "Java", EntryPointRequired: true, EntryPointName: "Main class", Extensions: []string{"java"}},
+		{Id: "kotlin", Name: "Kotlin", EntryPointRequired: true, EntryPointName: "Main class", Extensions: []string{"kt"}},
+		{Id: "python3", Name: "Python 3", Extensions: []string{"py"}},
+	}
+
+	defaultJudgementTypes = []JudgementType{
+		{Id: "AC", Name: "correct", Solved: true},
+		{Id: "WA", Name: "wrong answer", Penalty: true},
+		{Id: "TLE", Name: "time limit exceeded", Penalty: true},
+		{Id: "RTE", Name: "run-time error", Penalty: true},
+		{Id: "CE", Name: "compiler error"},
+	}
+
+	// expectedResults maps the verdict names used in @EXPECTED_RESULTS@ annotations to judgement types
+	expectedResults = map[string]string{
+		"CORRECT":        "AC",
+		"ACCEPTED":       "AC",
+		"WRONG-ANSWER":   "WA",
+		"WRONG_ANSWER":   "WA",
+		"TIMELIMIT":      "TLE",
+		"TIME_LIMIT":     "TLE",
+		"RUN-ERROR":      "RTE",
+		"RUN_TIME_ERROR": "RTE",
+		"COMPILER-ERROR": "CE",
+		"COMPILE_ERROR":  "CE",
+	}
+)
+
+// Server is a mock Contest API server for a single contest. Its exported fields must be set before it handles
+// requests.
+type Server struct {
+	// Verdicts maps problem ids or labels to the judgement type given to their submissions. Other problems get the
+	// DefaultVerdict. A submission can ask for another judgement type with an "@EXPECTED_RESULTS@: WA" comment.
+	Verdicts       map[string]string
+	DefaultVerdict string
+
+	// JudgeDelay is the time it takes to judge a submission or to answer a clarification. With no delay, judgements
+	// are final as soon as the submission is accepted.
+	JudgeDelay time.Duration
+
+	// ClarificationAnswer is sent in reply to every clarification of a team, unless it is empty.
+	ClarificationAnswer string
+
+	// Now returns the current time, and can be replaced to control the contest clock.
+	Now func() time.Time
+
+	mu             sync.Mutex
+	contest        Contest
+	problems       []Problem
+	languages      []Language
+	judgementTypes []JudgementType
+	teams          []Team
+	accounts       []Account
+	submissions    []Submission
+	files          map[string][]byte
+	judgements     []Judgement
+	runs           []Run
+	clarifications []Clarification
+	events         []event
+
+	// changed is closed and replaced whenever an event is added
+	changed chan struct{}
+}
+
+// New creates a server for the contest package. Missing languages and judgement types are replaced by common ones,
+// and when no teams are given there is a single team. When there are no accounts, every team gets an account with the
+// team id prefixed with "team" as username and password, and there is an admin account with admin as password. A
+// contest without start time starts now.
+func New(p Package) *Server {
+	s := &Server{
+		DefaultVerdict:      "AC",
+		ClarificationAnswer: "No comment, read the problem statement.",
+		Now:                 time.Now,
+		contest:             p.Contest,
+		problems:            p.Problems,
+		languages:           p.Languages,
+		judgementTypes:      p.JudgementTypes,
+		teams:               p.Teams,
+		accounts:            p.Accounts,
+		files:               map[string][]byte{},
+		changed:             make(chan struct{}),
+	}
+
+	if s.contest.Id == "" {
+		s.contest.Id = "mock"
+	}
+	if s.contest.Name == "" {
+		s.contest.Name = "Mock contest"
+	}
+	if s.contest.StartTime == nil {
+		now := time.Now().Truncate(time.Second)
+		s.contest.StartTime = &now
+	}
+	if s.contest.Duration == 0 {
+		s.contest.Duration = RelTime(defaultDuration)
+	}
+	if s.contest.PenaltyTime == 0 {
+		s.contest.PenaltyTime = defaultPenalty
+	}
+
+	if len(s.languages) == 0 {
+		s.languages = defaultLanguages
+	}
+	if len(s.judgementTypes) == 0 {
+		s.judgementTypes = defaultJudgementTypes
+	}
+	if len(s.teams) == 0 {
+		s.teams = []Team{{Id: "1", Name: "Team 1"}}
+	}
+	for i := range s.teams {
+		if s.teams[i].GroupIds == nil {
+			s.teams[i].GroupIds = []string{}
+		}
+	}
+
+	if len(s.accounts) == 0 {
+		for _, t := range s.teams {
+			username := t.Id
+			if !strings.HasPrefix(username, "team") {
+				username = "team" + username
+			}
+			s.accounts = append(s.accounts, Account{Id: username, Username: username, Password: username, Type: accountTypeTeam, TeamId: t.Id})
+		}
+		s.accounts = append(s.accounts, Account{Id: "admin", Username: "admin", Password: "admin", Type: accountTypeAdmin})
+	}
+
+	for i := range s.problems {
+		p := &s.problems[i]
+		base := "contests/" + s.contest.Id + "/problems/" + p.Id
+		if p.dir == "" {
+			continue
+		}
+		if statement := statementFile(p.dir); statement != "" {
+			p.Statement = []FileRef{{Href: base + "/statement", Mime: mimeType(statement), Filename: p.Label + filepath.Ext(statement)}}
+		}
+		p.Package = []FileRef{{Href: base + "/package", Mime: "application/zip", Filename: p.Id + ".zip"}}
+	}
+
+	c := s.contest
+	s.addEvent("contests", c.Id, c)
+	for _, jt := range s.judgementTypes {
+		s.addEvent("judgement-types", jt.Id, jt)
+	}
+	for _, l := range s.languages {
+		s.addEvent("languages", l.Id, l)
+	}
+	for _, p := range s.problems {
+		s.addEvent("problems", p.Id, p)
+	}
+	for _, t := range s.teams {
+		s.addEvent("teams", t.Id, t)
+	}
+	s.addEvent("state", "", s.state(time.Now()))
+
+	return s
+}
+
+// Load creates a server for the contest package in dir, see ReadPackage and New.
+func Load(dir string) (*Server, error) {
+	p, err := ReadPackage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(p), nil
+}
+
+// Contest returns the contest served.
+func (s *Server) Contest() Contest {
+	return s.contest
+}
+
+// Accounts returns the accounts that can be used to log in.
+func (s *Server) Accounts() []Account {
+	return append([]Account(nil), s.accounts...)
+}
+
+// ServeHTTP implements http.Handler. The API is served both at the root and below /api.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var path []string
+	for _, part := range strings.Split(strings.TrimPrefix(r.URL.Path, "/api"), "/") {
+		if part != "" {
+			path = append(path, part)
+		}
+	}
+
+	account, ok := s.authenticate(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="mockccs"`)
+		writeError(w, http.StatusUnauthorized, "invalid username or password")
+		return
+	}
+
+	switch {
+	case len(path) == 0:
+		writeJSON(w, map[string]string{"version": "2022-07", "version_url": "https://ccs-specs.icpc.io/2022-07/contest_api", "name": "mockccs"})
+	case path[0] != "contests":
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+	case len(path) == 1:
+		writeJSON(w, []Contest{s.contest})
+	case path[1] != s.contest.Id:
+		writeError(w, http.StatusNotFound, "unknown contest "+path[1])
+	case len(path) == 2:
+		writeJSON(w, s.contest)
+	case r.Method == http.MethodPost && len(path) == 3:
+		s.post(w, r, account, path[2])
+	case r.Method != http.MethodGet:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	default:
+		s.get(w, r, account, path[2:])
+	}
+}
+
+// authenticate returns the account of the basic auth credentials of the request. Requests without credentials are
+// anonymous and have no account, requests with invalid credentials are not ok.
+func (s *Server) authenticate(r *http.Request) (*Account, bool) {
+	username, password, hasAuth := r.BasicAuth()
+	if !hasAuth {
+		return nil, true
+	}
+
+	for i, a := range s.accounts {
+		if a.Username == username && a.Password == password {
+			return &s.accounts[i], true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, account *Account, path []string) {
+	now := s.Now()
+	switch path[0] {
+	case "state":
+		writeJSON(w, s.state(now))
+		return
+	case "scoreboard":
+		writeJSON(w, s.scoreboard(now))
+		return
+	case "account":
+		if account == nil {
+			writeError(w, http.StatusUnauthorized, "not logged in")
+			return
+		}
+		writeJSON(w, account)
+		return
+	case "event-feed":
+		s.eventFeed(w, r, account)
+		return
+	}
+
+	if len(path) == 3 && path[0] == "problems" {
+		s.problemFile(w, r, path[1], path[2])
+		return
+	}
+
+	if len(path) == 3 && path[0] == "submissions" && path[2] == "files" {
+		s.mu.Lock()
+		data, found := s.files[path[1]]
+		visible := found && s.visible(account, s.submissionById(path[1]))
+		s.mu.Unlock()
+
+		if !visible {
+			writeError(w, http.StatusNotFound, "unknown submission "+path[1])
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		_, _ = w.Write(data)
+		return
+	}
+
+	objects, found := s.objects(account, path[0], r)
+	if !found || len(path) > 2 {
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+		return
+	}
+
+	if len(path) == 1 {
+		writeJSON(w, objects)
+		return
+	}
+
+	for _, o := range objects {
+		if objectId(o) == path[1] {
+			writeJSON(w, o)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Sprintf("unknown object %s in %s", path[1], path[0]))
+}
+
+// objects returns the objects of an endpoint that are visible to the account.
+func (s *Server) objects(account *Account, endpoint string, r *http.Request) ([]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objects := []interface{}{}
+	add := func(o interface{}) {
+		if s.visible(account, o) {
+			objects = append(objects, o)
+		}
+	}
+
+	switch endpoint {
+	case "problems":
+		for _, o := range s.problems {
+			add(o)
+		}
+	case "languages":
+		for _, o := range s.languages {
+			add(o)
+		}
+	case "judgement-types":
+		for _, o := range s.judgementTypes {
+			add(o)
+		}
+	case "teams":
+		for _, o := range s.teams {
+			add(o)
+		}
+	case "accounts":
+		for _, o := range s.accounts {
+			add(o)
+		}
+	case "submissions":
+		for _, o := range s.submissions {
+			add(o)
+		}
+	case "judgements":
+		for _, o := range s.judgements {
+			if id := r.URL.Query().Get("submission_id"); id == "" || o.SubmissionId == id {
+				add(o)
+			}
+		}
+	case "runs":
+		for _, o := range s.runs {
+			if id := r.URL.Query().Get("judgement_id"); id == "" || o.JudgementId == id {
+				add(o)
+			}
+		}
+	case "clarifications":
+		for _, o := range s.clarifications {
+			add(o)
+		}
+	case "groups", "organizations", "awards":
+	default:
+		return nil, false
+	}
+
+	return objects, true
+}
+
+// visible returns whether the account may see an object. Judges and admins see everything, teams only see their own
+// submissions, judgements, runs and clarifications as well as broadcasts, and anonymous users only see broadcasts.
+func (s *Server) visible(account *Account, o interface{}) bool {
+	if account != nil && (account.Type == accountTypeJudge || account.Type == accountTypeAdmin) {
+		return true
+	}
+
+	var teamId string
+	if account != nil {
+		teamId = account.TeamId
+	}
+
+	switch o := o.(type) {
+	case Account:
+		return account != nil && o.Id == account.Id
+	case Submission:
+		return teamId != "" && o.TeamId == teamId
+	case Judgement:
+		return s.visible(account, s.submissionById(o.SubmissionId))
+	case Run:
+		for _, j := range s.judgements {
+			if j.Id == o.JudgementId {
+				return s.visible(account, j)
+			}
+		}
+		return false
+	case Clarification:
+		if o.FromTeamId == "" && o.ToTeamId == "" {
+			return true
+		}
+		return teamId != "" && (o.FromTeamId == teamId || o.ToTeamId == teamId)
+	case nil:
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) submissionById(id string) interface{} {
+	for _, sub := range s.submissions {
+		if sub.Id == id {
+			return sub
+		}
+	}
+
+	return nil
+}
+
+// problemFile serves the statement or the package of a problem. The package only contains problem.yaml and the
+// samples.
+func (s *Server) problemFile(w http.ResponseWriter, r *http.Request, id, file string) {
+	var dir string
+	for _, p := range s.problems {
+		if p.Id == id {
+			dir = p.dir
+		}
+	}
+
+	if dir == "" {
+		writeError(w, http.StatusNotFound, "no files for problem "+id)
+		return
+	}
+
+	switch file {
+	case "statement":
+		statement := statementFile(dir)
+		if statement == "" {
+			writeError(w, http.StatusNotFound, "no statement for problem "+id)
+			return
+		}
+		w.Header().Set("Content-Type", mimeType(statement))
+		http.ServeFile(w, r, statement)
+	case "package":
+		data, err := problemPackage(dir)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		_, _ = w.Write(data)
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint")
+	}
+}
+
+func (s *Server) post(w http.ResponseWriter, r *http.Request, account *Account, endpoint string) {
+	if account == nil {
+		writeError(w, http.StatusUnauthorized, "not logged in")
+		return
+	}
+
+	var (
+		created interface{}
+		err     error
+	)
+	switch endpoint {
+	case "submissions":
+		created, err = s.submit(r, account)
+	case "clarifications":
+		created, err = s.clarify(r, account)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var status statusError
+	if errors.As(err, &status) {
+		writeError(w, status.code, status.message)
+		return
+	} else if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, created)
+}
+
+// statusError is returned by the handlers of POST requests to respond with a status other than 400
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e statusError) Error() string {
+	return e.message
+}
+
+func (s *Server) submit(r *http.Request, account *Account) (Submission, error) {
+	var body struct {
+		ProblemId  string `json:"problem_id"`
+		LanguageId string `json:"language_id"`
+		TeamId     string `json:"team_id"`
+		EntryPoint string `json:"entry_point"`
+		Files      []struct {
+			Data string `json:"data"`
+		} `json:"files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return Submission{}, fmt.Errorf("invalid submission; %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	teamId := account.TeamId
+	if account.Type != accountTypeTeam && body.TeamId != "" {
+		teamId = body.TeamId
+	} else if body.TeamId != "" && body.TeamId != teamId {
+		return Submission{}, statusError{http.StatusForbidden, "can not submit for another team"}
+	}
+
+	if !s.hasTeam(teamId) {
+		return Submission{}, statusError{http.StatusForbidden, "submissions require a team"}
+	}
+
+	problem, found := s.problemById(body.ProblemId)
+	if !found {
+		return Submission{}, fmt.Errorf("unknown problem %s", body.ProblemId)
+	}
+
+	var language Language
+	for _, l := range s.languages {
+		if l.Id == body.LanguageId {
+			language, found = l, true
+		}
+	}
+	if !found {
+		return Submission{}, fmt.Errorf("unknown language %s", body.LanguageId)
+	}
+
+	if language.EntryPointRequired && body.EntryPoint == "" {
+		return Submission{}, fmt.Errorf("language %s requires an entry point", language.Name)
+	}
+
+	if len(body.Files) != 1 {
+		return Submission{}, errors.New("expected a single files archive")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(body.Files[0].Data)
+	if err != nil {
+		return Submission{}, fmt.Errorf("invalid files archive; %v", err)
+	}
+	if _, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+		return Submission{}, fmt.Errorf("invalid files archive; %v", err)
+	}
+
+	now := s.Now()
+	state := s.state(now)
+	if state.Started == nil {
+		return Submission{}, statusError{http.StatusForbidden, "the contest has not started"}
+	}
+	if state.Ended != nil {
+		return Submission{}, statusError{http.StatusForbidden, "the contest has ended"}
+	}
+
+	id := strconv.Itoa(len(s.submissions) + 1)
+	sub := Submission{
+		Id:          id,
+		LanguageId:  language.Id,
+		ProblemId:   problem.Id,
+		TeamId:      teamId,
+		EntryPoint:  body.EntryPoint,
+		Time:        now,
+		ContestTime: s.contestTime(now),
+		Files:       []FileRef{{Href: "contests/" + s.contest.Id + "/submissions/" + id + "/files", Mime: "application/zip"}},
+	}
+	s.submissions = append(s.submissions, sub)
+	s.files[id] = data
+	s.addEvent("submissions", sub.Id, sub)
+
+	judgement := Judgement{
+		Id:               id,
+		SubmissionId:     id,
+		StartTime:        now,
+		StartContestTime: sub.ContestTime,
+	}
+	s.judgements = append(s.judgements, judgement)
+	s.addEvent("judgements", judgement.Id, judgement)
+
+	verdict := s.verdict(problem, data)
+	s.later(func() {
+		s.judge(judgement.Id, verdict)
+	})
+
+	return sub, nil
+}
+
+func (s *Server) clarify(r *http.Request, account *Account) (Clarification, error) {
+	var body struct {
+		ProblemId string `json:"problem_id"`
+		ToTeamId  string `json:"to_team_id"`
+		ReplyToId string `json:"reply_to_id"`
+		Text      string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return Clarification{}, fmt.Errorf("invalid clarification; %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.TrimSpace(body.Text) == "" {
+		return Clarification{}, errors.New("clarification text is empty")
+	}
+
+	if _, found := s.problemById(body.ProblemId); body.ProblemId != "" && !found {
+		return Clarification{}, fmt.Errorf("unknown problem %s", body.ProblemId)
+	}
+
+	now := s.Now()
+	clar := Clarification{
+		Id:          strconv.Itoa(len(s.clarifications) + 1),
+		ProblemId:   body.ProblemId,
+		Text:        body.Text,
+		Time:        now,
+		ContestTime: s.contestTime(now),
+	}
+
+	if account.Type == accountTypeTeam {
+		if body.ToTeamId != "" || body.ReplyToId != "" {
+			return Clarification{}, statusError{http.StatusForbidden, "teams can only ask clarifications"}
+		}
+		clar.FromTeamId = account.TeamId
+	} else {
+		if body.ToTeamId != "" && !s.hasTeam(body.ToTeamId) {
+			return Clarification{}, fmt.Errorf("unknown team %s", body.ToTeamId)
+		}
+
+		var found bool
+		for _, c := range s.clarifications {
+			found = found || c.Id == body.ReplyToId
+		}
+		if body.ReplyToId != "" && !found {
+			return Clarification{}, fmt.Errorf("unknown clarification %s", body.ReplyToId)
+		}
+
+		clar.ToTeamId = body.ToTeamId
+		clar.ReplyToId = body.ReplyToId
+	}
+
+	s.clarifications = append(s.clarifications, clar)
+	s.addEvent("clarifications", clar.Id, clar)
+
+	if clar.FromTeamId != "" && s.ClarificationAnswer != "" {
+		s.later(func() {
+			s.answer(clar)
+		})
+	}
+
+	return clar, nil
+}
+
+func (s *Server) hasTeam(id string) bool {
+	for _, t := range s.teams {
+		if id != "" && t.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) problemById(id string) (Problem, bool) {
+	for _, p := range s.problems {
+		if p.Id == id {
+			return p, true
+		}
+	}
+
+	return Problem{}, false
+}
+
+func (s *Server) contestTime(t time.Time) RelTime {
+	return RelTime(t.Sub(*s.contest.StartTime))
+}
+
+// later runs f after the JudgeDelay, holding the lock. Without delay, f runs immediately, and the lock must already be
+// held.
+func (s *Server) later(f func()) {
+	if s.JudgeDelay <= 0 {
+		f()
+		return
+	}
+
+	time.AfterFunc(s.JudgeDelay, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		f()
+	})
+}
+
+// addEvent adds an event to the event feed and wakes up its listeners. The lock must be held, unless the server is
+// being created.
+func (s *Server) addEvent(eventType, id string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+
+	s.events = append(s.events, event{
+		Type:   eventType,
+		Id:     id,
+		Data:   encoded,
+		Token:  strconv.Itoa(len(s.events) + 1),
+		object: data,
+	})
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(apiError{Code: code, Message: message})
+}
+
+func mimeType(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		return "application/pdf"
+	case ".html":
+		return "text/html"
+	case ".md":
+		return "text/markdown"
+	}
+
+	return "text/plain"
+}
+
+// objectId returns the id of an object served by the API.
+func objectId(o interface{}) string {
+	switch o := o.(type) {
+	case Problem:
+		return o.Id
+	case Language:
+		return o.Id
+	case JudgementType:
+		return o.Id
+	case Team:
+		return o.Id
+	case Account:
+		return o.Id
+	case Submission:
+		return o.Id
+	case Judgement:
+		return o.Id
+	case Run:
+		return o.Id
+	case Clarification:
+		return o.Id
+	}
+
+	return ""
+}
@@ -0,0 +1,242 @@
+package mockccs
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	interactor "github.com/icpctools/api-interactor"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePackage(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, contents := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(contents), 0644))
+	}
+
+	return dir
+}
+
+func testPackage(t *testing.T) string {
+	return writePackage(t, map[string]string{
+		"contest.yaml": "id: practice\nname: Practice\nstart-time: 2021-04-01T10:00:00+00:00\nduration: 5:00:00\n" +
+			"scoreboard-freeze-length: 1:00:00\npenalty-time: 20\n",
+		"problemset.yaml":                     "problems:\n  - letter: A\n    short-name: hello\n  - letter: B\n    short-name: sum\n",
+		"hello/problem.yaml":                  "name:\n  en: Hello World\n  nl: Hallo Wereld\n",
+		"hello/data/sample/1.in":              "1\n",
+		"hello/data/sample/1.ans":             "Hello\n",
+		"hello/data/secret/2.in":              "2\n",
+		"hello/problem_statement/problem.pdf": "%PDF",
+		"problems/sum/problem.yaml":           "name: Sum\n",
+		"teams.tsv":                           "teams\t1\n1\t1001\t2\tAlpha\n2\t1002\t2\tBeta\n",
+	})
+}
+
+func TestReadPackage(t *testing.T) {
+	p, err := ReadPackage(testPackage(t))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "practice", p.Contest.Id)
+	assert.Equal(t, time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC), p.Contest.StartTime.UTC())
+	assert.Equal(t, 5*time.Hour, p.Contest.Duration.Duration())
+	assert.Equal(t, time.Hour, p.Contest.ScoreboardFreezeDuration.Duration())
+	assert.Equal(t, 20, p.Contest.PenaltyTime)
+
+	if assert.Len(t, p.Problems, 2) {
+		assert.Equal(t, "Hello World", p.Problems[0].Name)
+		assert.Equal(t, 2, p.Problems[0].TestDataCount)
+		assert.Equal(t, "Sum", p.Problems[1].Name)
+		assert.Equal(t, "B", p.Problems[1].Label)
+	}
+
+	assert.Equal(t, []Team{
+		{Id: "1", ICPCId: "1001", Name: "Alpha", GroupIds: []string{"2"}},
+		{Id: "2", ICPCId: "1002", Name: "Beta", GroupIds: []string{"2"}},
+	}, p.Teams)
+
+	_, err = ReadPackage(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestRelTime(t *testing.T) {
+	for input, expected := range map[string]time.Duration{
+		"5:00:00":     5 * time.Hour,
+		"0:30:00.5":   30*time.Minute + 500*time.Millisecond,
+		"-1:02:03.04": -(time.Hour + 2*time.Minute + 3*time.Second + 40*time.Millisecond),
+		"1:30":        time.Hour + 30*time.Minute,
+		"90m":         90 * time.Minute,
+	} {
+		d, err := ParseRelTime(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, d.Duration(), input)
+	}
+
+	_, err := ParseRelTime("five hours")
+	assert.Error(t, err)
+
+	data, err := json.Marshal(RelTime(-(time.Hour + 2*time.Minute + 3*time.Second + 40*time.Millisecond)))
+	assert.NoError(t, err)
+	assert.Equal(t, `"-1:02:03.040"`, string(data))
+}
+
+// newTestServer serves the test package, with the contest started an hour ago
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	p, err := ReadPackage(testPackage(t))
+	assert.NoError(t, err)
+
+	start := time.Now().Add(-time.Hour)
+	p.Contest.StartTime = &start
+
+	s := New(p)
+	s.Verdicts = map[string]string{"B": "WRONG-ANSWER"}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	return s, ts
+}
+
+func TestServerWithInteractor(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	api, err := interactor.ContestInteractor(ts.URL, "team1", "team1", "practice", false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	account, err := api.Account()
+	assert.NoError(t, err)
+	assert.Equal(t, "1", account.TeamId)
+
+	state, err := api.State()
+	assert.NoError(t, err)
+	assert.NotNil(t, state.Started)
+	assert.Nil(t, state.Ended)
+
+	var files interactor.LocalFileReference
+	assert.NoError(t, files.FromString("hello.py", "print('Hello')"))
+	accepted, err := api.PostSubmission("hello", "python3", "", files)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", accepted.TeamId)
+
+	files = interactor.LocalFileReference{}
+	assert.NoError(t, files.FromString("sum.py", "# @EXPECTED_RESULTS@: TIMELIMIT\n"))
+	asked, err := api.PostSubmission("sum", "python3", "", files)
+	assert.NoError(t, err)
+
+	files = interactor.LocalFileReference{}
+	assert.NoError(t, files.FromString("sum.py", "print(3)"))
+	rejected, err := api.PostSubmission("sum", "python3", "", files)
+	assert.NoError(t, err)
+
+	_, err = api.PostSubmission("hello", "java", "", files)
+	assert.EqualError(t, err, "language Java requires an entry point (error code 400)")
+
+	judgements, err := api.Judgements()
+	assert.NoError(t, err)
+	verdicts := map[string]string{}
+	for _, j := range judgements {
+		verdicts[j.SubmissionId] = j.JudgementTypeId
+	}
+	assert.Equal(t, map[string]string{accepted.Id: "AC", asked.Id: "TLE", rejected.Id: "WA"}, verdicts)
+
+	clar, err := api.PostClarification("hello", "Is the input always 1?")
+	assert.NoError(t, err)
+
+	clars, err := api.Clarifications()
+	assert.NoError(t, err)
+	if assert.Len(t, clars, 2) {
+		assert.Equal(t, clar.Id, clars[1].ReplyToId)
+		assert.Equal(t, "1", clars[1].ToTeamId)
+	}
+
+	scoreboard, err := api.Scoreboard()
+	assert.NoError(t, err)
+	if assert.Len(t, scoreboard.Rows, 2) {
+		assert.Equal(t, interactor.Identifier("1"), scoreboard.Rows[0].TeamId)
+		assert.Equal(t, 1, scoreboard.Rows[0].Score.NumSolved)
+		assert.Equal(t, 2, scoreboard.Rows[1].Rank)
+	}
+}
+
+func TestServerVisibility(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	team1, err := interactor.ContestInteractor(ts.URL, "team1", "team1", "practice", false)
+	assert.NoError(t, err)
+	team2, err := interactor.ContestInteractor(ts.URL, "team2", "team2", "practice", false)
+	assert.NoError(t, err)
+	admin, err := interactor.ContestInteractor(ts.URL, "admin", "admin", "practice", false)
+	assert.NoError(t, err)
+
+	var files interactor.LocalFileReference
+	assert.NoError(t, files.FromString("hello.py", "print('Hello')"))
+	_, err = team1.PostSubmission("hello", "python3", "", files)
+	assert.NoError(t, err)
+
+	for api, expected := range map[interactor.ContestApi]int{team1: 1, team2: 0, admin: 1} {
+		submissions, err := api.Submissions()
+		assert.NoError(t, err)
+		assert.Len(t, submissions, expected)
+	}
+
+	_, err = admin.Submit(interactor.Clarification{ToTeamId: "2", Text: "Only for team 2"})
+	assert.NoError(t, err)
+
+	clars, err := team1.Clarifications()
+	assert.NoError(t, err)
+	assert.Len(t, clars, 0)
+
+	clars, err = team2.Clarifications()
+	assert.NoError(t, err)
+	assert.Len(t, clars, 1)
+
+	_, err = interactor.ContestInteractor(ts.URL, "team1", "wrong", "practice", false)
+	assert.Error(t, err)
+}
+
+func TestEventFeed(t *testing.T) {
+	s, ts := newTestServer(t)
+	s.JudgeDelay = time.Hour
+
+	api, err := interactor.ContestInteractor(ts.URL, "team1", "team1", "practice", false)
+	assert.NoError(t, err)
+
+	var files interactor.LocalFileReference
+	assert.NoError(t, files.FromString("hello.py", "print('Hello')"))
+	_, err = api.PostSubmission("hello", "python3", "", files)
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/contests/practice/event-feed?stream=false&types=submissions,judgements", nil)
+	assert.NoError(t, err)
+	req.SetBasicAuth("team1", "team1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	var types []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var ev struct {
+			Type  string `json:"type"`
+			Token string `json:"token"`
+		}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &ev))
+		assert.NotEmpty(t, ev.Token)
+		types = append(types, ev.Type)
+	}
+
+	// The judgement is not final yet, so it was only created
+	assert.Equal(t, []string{"submissions", "judgements"}, types)
+}
@@ -0,0 +1,366 @@
+package mockccs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Package contains the configuration of a contest. Empty parts are filled with defaults by New.
+type Package struct {
+	Contest        Contest
+	Problems       []Problem
+	Languages      []Language
+	JudgementTypes []JudgementType
+	Teams          []Team
+	Accounts       []Account
+}
+
+type (
+	// contestYaml is contest.yaml, in both the older dashed and the newer underscored format
+	contestYaml struct {
+		Id                       string `yaml:"id"`
+		Name                     string `yaml:"name"`
+		FormalName               string `yaml:"formal-name"`
+		FormalName2              string `yaml:"formal_name"`
+		StartTime                string `yaml:"start-time"`
+		StartTime2               string `yaml:"start_time"`
+		Duration                 string `yaml:"duration"`
+		ScoreboardFreezeLength   string `yaml:"scoreboard-freeze-length"`
+		ScoreboardFreezeDuration string `yaml:"scoreboard_freeze_duration"`
+		PenaltyTime              string `yaml:"penalty-time"`
+		PenaltyTime2             string `yaml:"penalty_time"`
+	}
+
+	// problemsetYaml is problemset.yaml, listing the problems by letter and short name
+	problemsetYaml struct {
+		Problems []struct {
+			Letter    string `yaml:"letter"`
+			ShortName string `yaml:"short-name"`
+			Name      string `yaml:"name"`
+			Color     string `yaml:"color"`
+			Rgb       string `yaml:"rgb"`
+		} `yaml:"problems"`
+	}
+)
+
+// startTimeFormats are the formats accepted for the start time in contest.yaml
+var startTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z07",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+// statementFiles are the statement files looked for in a problem directory, in order
+var statementFiles = []string{
+	"problem_statement/problem.pdf",
+	"problem_statement/problem.en.pdf",
+	"problem.pdf",
+	"statement/problem.pdf",
+	"problem_statement/problem.html",
+	"problem_statement/problem.md",
+}
+
+// ReadPackage reads a contest package directory. It contains contest.yaml, the problems in problemset.yaml or
+// problems.yaml, and optionally teams, accounts, languages and judgement-types as .yaml or .json files in the format of
+// the Contest API. Teams can also be given in the teams.tsv format. The problem directories are looked for in the
+// package directory and in its problems directory, to serve their statement and samples.
+func ReadPackage(dir string) (Package, error) {
+	var p Package
+	if info, err := os.Stat(dir); err != nil {
+		return p, fmt.Errorf("could not read contest package; %w", err)
+	} else if !info.IsDir() {
+		return p, fmt.Errorf("contest package %s is not a directory", dir)
+	}
+
+	contest, err := readContest(dir)
+	if err != nil {
+		return p, err
+	}
+	p.Contest = contest
+
+	if p.Problems, err = readProblems(dir); err != nil {
+		return p, err
+	}
+
+	for _, list := range []struct {
+		name string
+		v    interface{}
+	}{
+		{"languages", &p.Languages},
+		{"judgement-types", &p.JudgementTypes},
+		{"teams", &p.Teams},
+		{"accounts", &p.Accounts},
+	} {
+		if _, err := readList(dir, list.name, list.v); err != nil {
+			return p, err
+		}
+	}
+
+	if len(p.Teams) == 0 {
+		if p.Teams, err = readTeamsTsv(filepath.Join(dir, "teams.tsv")); err != nil {
+			return p, err
+		}
+	}
+
+	return p, nil
+}
+
+func readContest(dir string) (Contest, error) {
+	var c Contest
+	var y contestYaml
+	if _, err := readYaml(filepath.Join(dir, "contest.yaml"), &y); err != nil {
+		return c, err
+	}
+
+	c.Id = y.Id
+	if c.Id == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return c, err
+		}
+		c.Id = strings.ToLower(filepath.Base(abs))
+	}
+
+	c.Name = y.Name
+	if c.Name == "" {
+		c.Name = c.Id
+	}
+	c.FormalName = firstNonEmpty(y.FormalName, y.FormalName2)
+
+	if start := firstNonEmpty(y.StartTime, y.StartTime2); start != "" && start != "undefined" {
+		var parsed bool
+		for _, format := range startTimeFormats {
+			if t, err := time.Parse(format, start); err == nil {
+				c.StartTime = &t
+				parsed = true
+				break
+			}
+		}
+		if !parsed {
+			return c, fmt.Errorf("invalid start time %s in contest.yaml", start)
+		}
+	}
+
+	if y.Duration != "" {
+		d, err := ParseRelTime(y.Duration)
+		if err != nil {
+			return c, fmt.Errorf("invalid duration in contest.yaml; %w", err)
+		}
+		c.Duration = d
+	}
+
+	if freeze := firstNonEmpty(y.ScoreboardFreezeLength, y.ScoreboardFreezeDuration); freeze != "" {
+		d, err := ParseRelTime(freeze)
+		if err != nil {
+			return c, fmt.Errorf("invalid scoreboard freeze duration in contest.yaml; %w", err)
+		}
+		c.ScoreboardFreezeDuration = d
+	}
+
+	if penalty := firstNonEmpty(y.PenaltyTime, y.PenaltyTime2); penalty != "" {
+		if minutes, err := strconv.Atoi(penalty); err == nil {
+			c.PenaltyTime = minutes
+		} else if d, err := ParseRelTime(penalty); err == nil {
+			c.PenaltyTime = int(d.Duration().Minutes())
+		} else {
+			return c, fmt.Errorf("invalid penalty time %s in contest.yaml", penalty)
+		}
+	}
+
+	return c, nil
+}
+
+func readProblems(dir string) ([]Problem, error) {
+	var problems []Problem
+	found, err := readList(dir, "problems", &problems)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		var set problemsetYaml
+		if _, err := readYaml(filepath.Join(dir, "problemset.yaml"), &set); err != nil {
+			return nil, err
+		}
+
+		for _, p := range set.Problems {
+			problems = append(problems, Problem{Id: p.ShortName, Label: p.Letter, Name: p.Name, Color: p.Color, Rgb: p.Rgb})
+		}
+	}
+
+	for i := range problems {
+		p := &problems[i]
+		if p.Id == "" {
+			return nil, fmt.Errorf("problem %d in contest package has no id", i+1)
+		}
+		if p.Label == "" {
+			p.Label = string(rune('A' + i))
+		}
+		if p.Ordinal == 0 {
+			p.Ordinal = i + 1
+		}
+
+		for _, candidate := range []string{filepath.Join(dir, p.Id), filepath.Join(dir, "problems", p.Id)} {
+			if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+				p.dir = candidate
+				break
+			}
+		}
+
+		if p.dir == "" {
+			continue
+		}
+
+		if p.Name == "" {
+			p.Name = problemName(p.dir)
+		}
+		if p.TestDataCount == 0 {
+			p.TestDataCount = countTestData(p.dir)
+		}
+	}
+
+	for i := range problems {
+		if problems[i].Name == "" {
+			problems[i].Name = problems[i].Id
+		}
+	}
+
+	return problems, nil
+}
+
+// problemName returns the name in problem.yaml of a problem directory, preferring English when there are several.
+func problemName(dir string) string {
+	var y struct {
+		Name interface{} `yaml:"name"`
+	}
+	if _, err := readYaml(filepath.Join(dir, "problem.yaml"), &y); err != nil {
+		return ""
+	}
+
+	switch name := y.Name.(type) {
+	case string:
+		return name
+	case map[string]interface{}:
+		if en, ok := name["en"].(string); ok {
+			return en
+		}
+
+		var languages []string
+		for l := range name {
+			languages = append(languages, l)
+		}
+		sort.Strings(languages)
+		for _, l := range languages {
+			if s, ok := name[l].(string); ok {
+				return s
+			}
+		}
+	}
+
+	return ""
+}
+
+// countTestData returns the number of test cases in the data directory of a problem.
+func countTestData(dir string) int {
+	var count int
+	_ = filepath.Walk(filepath.Join(dir, "data"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(path, ".in") {
+			count++
+		}
+		return nil
+	})
+
+	return count
+}
+
+// statementFile returns the statement file of a problem directory, or an empty string if there is none.
+func statementFile(dir string) string {
+	for _, name := range statementFiles {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if info, err := os.Stat(filename); err == nil && !info.IsDir() {
+			return filename
+		}
+	}
+
+	return ""
+}
+
+// readList reads a list of objects from <name>.yaml, <name>.yml or <name>.json in dir, and returns whether one of the
+// files exists. JSON is read as YAML, of which it is a subset.
+func readList(dir, name string, v interface{}) (bool, error) {
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		found, err := readYaml(filepath.Join(dir, name+ext), v)
+		if found || err != nil {
+			return found, err
+		}
+	}
+
+	return false, nil
+}
+
+// readYaml decodes a YAML file into v, and returns whether it exists.
+func readYaml(filename string, v interface{}) (bool, error) {
+	data, err := ioutil.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("could not read %s; %w", filename, err)
+	}
+
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return true, fmt.Errorf("could not parse %s; %w", filename, err)
+	}
+
+	return true, nil
+}
+
+// readTeamsTsv reads teams in the tab separated format, with a header line followed by the team id, ICPC id, group
+// id and name on every line.
+func readTeamsTsv(filename string) ([]Team, error) {
+	f, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read %s; %w", filename, err)
+	}
+	defer f.Close()
+
+	var teams []Team
+	scanner := bufio.NewScanner(f)
+	for line := 0; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if line == 0 || len(fields) < 4 {
+			continue
+		}
+
+		team := Team{Id: fields[0], ICPCId: fields[1], Name: fields[3]}
+		if fields[2] != "" {
+			team.GroupIds = []string{fields[2]}
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, scanner.Err()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,217 @@
+package mockccs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+type (
+	// RelTime is a duration which marshals to the h:mm:ss.uuu format of the Contest API.
+	RelTime time.Duration
+
+	// Contest is the contest served by the mock server. A contest without start time starts when the server is created.
+	Contest struct {
+		Id                       string     `json:"id"`
+		Name                     string     `json:"name"`
+		FormalName               string     `json:"formal_name,omitempty"`
+		StartTime                *time.Time `json:"start_time"`
+		Duration                 RelTime    `json:"duration"`
+		ScoreboardFreezeDuration RelTime    `json:"scoreboard_freeze_duration,omitempty"`
+		PenaltyTime              int        `json:"penalty_time"`
+	}
+
+	FileRef struct {
+		Href     string `json:"href"`
+		Mime     string `json:"mime"`
+		Filename string `json:"filename,omitempty"`
+	}
+
+	Problem struct {
+		Id            string    `json:"id" yaml:"id"`
+		Label         string    `json:"label" yaml:"label"`
+		Name          string    `json:"name" yaml:"name"`
+		Ordinal       int       `json:"ordinal" yaml:"ordinal"`
+		Color         string    `json:"color,omitempty" yaml:"color"`
+		Rgb           string    `json:"rgb,omitempty" yaml:"rgb"`
+		TestDataCount int       `json:"test_data_count" yaml:"test_data_count"`
+		Statement     []FileRef `json:"statement,omitempty" yaml:"-"`
+		Package       []FileRef `json:"package,omitempty" yaml:"-"`
+
+		// dir is the directory of the problem in the contest package, if any
+		dir string
+	}
+
+	Language struct {
+		Id                 string   `json:"id" yaml:"id"`
+		Name               string   `json:"name" yaml:"name"`
+		EntryPointRequired bool     `json:"entry_point_required" yaml:"entry_point_required"`
+		EntryPointName     string   `json:"entry_point_name,omitempty" yaml:"entry_point_name"`
+		Extensions         []string `json:"extensions" yaml:"extensions"`
+	}
+
+	JudgementType struct {
+		Id      string `json:"id" yaml:"id"`
+		Name    string `json:"name" yaml:"name"`
+		Penalty bool   `json:"penalty" yaml:"penalty"`
+		Solved  bool   `json:"solved" yaml:"solved"`
+	}
+
+	Team struct {
+		Id             string   `json:"id" yaml:"id"`
+		ICPCId         string   `json:"icpc_id,omitempty" yaml:"icpc_id"`
+		Name           string   `json:"name" yaml:"name"`
+		DisplayName    string   `json:"display_name,omitempty" yaml:"display_name"`
+		GroupIds       []string `json:"group_ids" yaml:"group_ids"`
+		OrganizationId string   `json:"organization_id,omitempty" yaml:"organization_id"`
+	}
+
+	// Account is used to authenticate to the mock server. The password is never served.
+	Account struct {
+		Id       string `json:"id" yaml:"id"`
+		Username string `json:"username" yaml:"username"`
+		Password string `json:"-" yaml:"password"`
+		Type     string `json:"type" yaml:"type"`
+		TeamId   string `json:"team_id,omitempty" yaml:"team_id"`
+	}
+
+	Submission struct {
+		Id          string    `json:"id"`
+		LanguageId  string    `json:"language_id"`
+		ProblemId   string    `json:"problem_id"`
+		TeamId      string    `json:"team_id"`
+		EntryPoint  string    `json:"entry_point,omitempty"`
+		Time        time.Time `json:"time"`
+		ContestTime RelTime   `json:"contest_time"`
+		Files       []FileRef `json:"files"`
+	}
+
+	Judgement struct {
+		Id               string     `json:"id"`
+		SubmissionId     string     `json:"submission_id"`
+		JudgementTypeId  string     `json:"judgement_type_id,omitempty"`
+		StartTime        time.Time  `json:"start_time"`
+		StartContestTime RelTime    `json:"start_contest_time"`
+		EndTime          *time.Time `json:"end_time,omitempty"`
+		EndContestTime   *RelTime   `json:"end_contest_time,omitempty"`
+		MaxRunTime       float64    `json:"max_run_time,omitempty"`
+	}
+
+	Run struct {
+		Id              string    `json:"id"`
+		JudgementId     string    `json:"judgement_id"`
+		Ordinal         int       `json:"ordinal"`
+		JudgementTypeId string    `json:"judgement_type_id"`
+		Time            time.Time `json:"time"`
+		ContestTime     RelTime   `json:"contest_time"`
+		RunTime         float64   `json:"run_time"`
+	}
+
+	Clarification struct {
+		Id          string    `json:"id"`
+		FromTeamId  string    `json:"from_team_id,omitempty"`
+		ToTeamId    string    `json:"to_team_id,omitempty"`
+		ReplyToId   string    `json:"reply_to_id,omitempty"`
+		ProblemId   string    `json:"problem_id,omitempty"`
+		Text        string    `json:"text"`
+		Time        time.Time `json:"time"`
+		ContestTime RelTime   `json:"contest_time"`
+	}
+
+	State struct {
+		Started      *time.Time `json:"started"`
+		Frozen       *time.Time `json:"frozen"`
+		Ended        *time.Time `json:"ended"`
+		Thawed       *time.Time `json:"thawed"`
+		Finalized    *time.Time `json:"finalized"`
+		EndOfUpdates *time.Time `json:"end_of_updates"`
+	}
+
+	Scoreboard struct {
+		Time        time.Time       `json:"time"`
+		ContestTime RelTime         `json:"contest_time"`
+		State       State           `json:"state"`
+		Rows        []ScoreboardRow `json:"rows"`
+	}
+
+	ScoreboardRow struct {
+		Rank     int            `json:"rank"`
+		TeamId   string         `json:"team_id"`
+		Score    Score          `json:"score"`
+		Problems []ScoreProblem `json:"problems"`
+	}
+
+	Score struct {
+		NumSolved int `json:"num_solved"`
+		TotalTime int `json:"total_time"`
+	}
+
+	ScoreProblem struct {
+		ProblemId  string `json:"problem_id"`
+		NumJudged  int    `json:"num_judged"`
+		NumPending int    `json:"num_pending"`
+		Solved     bool   `json:"solved"`
+		Time       int    `json:"time,omitempty"`
+	}
+
+	// apiError is the body of error responses, as expected by the interactor
+	apiError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+)
+
+var relTimePattern = regexp.MustCompile(`^(-?)(\d+):(\d{2})(?::(\d{2}))?(?:\.(\d{1,3}))?$`)
+
+func (r RelTime) Duration() time.Duration {
+	return time.Duration(r)
+}
+
+func (r RelTime) String() string {
+	d := time.Duration(r)
+	var sign string
+	if d < 0 {
+		sign, d = "-", -d
+	}
+
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%s%d:%02d:%02d.%03d", sign, ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+func (r RelTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// ParseRelTime parses a duration in the h:mm:ss(.uuu) format used by contest packages and the Contest API. Go
+// durations such as 5h are accepted as well.
+func ParseRelTime(s string) (RelTime, error) {
+	sm := relTimePattern.FindStringSubmatch(s)
+	if sm == nil {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+		return RelTime(d), nil
+	}
+
+	var parts [4]int
+	for i, p := range sm[2:] {
+		if i == 3 {
+			// Milliseconds may be given with fewer digits
+			for len(p) < 3 {
+				p += "0"
+			}
+		}
+		parts[i], _ = strconv.Atoi(p)
+	}
+
+	d := time.Duration(parts[0])*time.Hour + time.Duration(parts[1])*time.Minute +
+		time.Duration(parts[2])*time.Second + time.Duration(parts[3])*time.Millisecond
+	if sm[1] == "-" {
+		d = -d
+	}
+
+	return RelTime(d), nil
+}